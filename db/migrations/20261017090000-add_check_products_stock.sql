@@ -0,0 +1,6 @@
+
+-- +migrate Up
+ALTER TABLE products ADD CONSTRAINT products_stock_non_negative CHECK ("stock" >= 0);
+
+-- +migrate Down
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_stock_non_negative;
@@ -0,0 +1,35 @@
+
+-- +migrate Up
+ALTER TYPE "status" RENAME TO "status_old";
+
+CREATE TYPE "status" AS ENUM ('pending', 'paid', 'processing', 'shipped', 'delivered', 'cancelled', 'refunded', 'failed');
+
+ALTER TABLE orders ALTER COLUMN "status" DROP DEFAULT;
+ALTER TABLE orders ALTER COLUMN "status" TYPE "status" USING (
+    CASE "status"::text
+        WHEN 'success' THEN 'paid'
+        ELSE "status"::text
+    END
+)::"status";
+ALTER TABLE orders ALTER COLUMN "status" SET DEFAULT 'pending';
+
+DROP TYPE "status_old";
+
+-- +migrate Down
+ALTER TYPE "status" RENAME TO "status_new";
+
+CREATE TYPE "status" AS ENUM ('pending', 'success', 'failed');
+
+ALTER TABLE orders ALTER COLUMN "status" DROP DEFAULT;
+ALTER TABLE orders ALTER COLUMN "status" TYPE "status" USING (
+    CASE "status"::text
+        WHEN 'pending' THEN 'pending'
+        WHEN 'cancelled' THEN 'failed'
+        WHEN 'refunded' THEN 'failed'
+        WHEN 'failed' THEN 'failed'
+        ELSE 'success'
+    END
+)::"status";
+ALTER TABLE orders ALTER COLUMN "status" SET DEFAULT 'pending';
+
+DROP TYPE "status_new";
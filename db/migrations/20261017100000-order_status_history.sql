@@ -0,0 +1,17 @@
+
+-- +migrate Up
+CREATE TABLE order_status_history (
+    "id" SERIAL PRIMARY KEY,
+    "order_id" VARCHAR(100) NOT NULL REFERENCES orders("id") ON DELETE CASCADE,
+    "from_status" status,
+    "to_status" status NOT NULL,
+    "actor_user_id" INT REFERENCES users("id") ON DELETE SET NULL,
+    "actor_service" VARCHAR(100),
+    "reason" TEXT,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX order_status_history_order_id_idx ON order_status_history ("order_id", "created_at");
+
+-- +migrate Down
+DROP TABLE IF EXISTS order_status_history;
@@ -0,0 +1,16 @@
+
+-- +migrate Up
+CREATE TABLE order_id_counters (
+    "day" DATE PRIMARY KEY,
+    "last_value" BIGINT NOT NULL
+);
+
+-- Continue numbering after the IDs already issued by the old COUNT(*) scheme.
+INSERT INTO order_id_counters ("day", "last_value")
+SELECT to_date(split_part("id", '-', 2), 'YYYYMMDD'), MAX(split_part("id", '-', 3)::BIGINT)
+FROM orders
+WHERE "id" ~ '^ORD-[0-9]{8}-[0-9]+$'
+GROUP BY 1;
+
+-- +migrate Down
+DROP TABLE IF EXISTS order_id_counters;
@@ -0,0 +1,15 @@
+
+-- +migrate Up
+CREATE TABLE idempotency_keys (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL REFERENCES users("id") ON DELETE CASCADE,
+    "key" VARCHAR(255) NOT NULL,
+    "request_hash" CHAR(64) NOT NULL,
+    "order_id" VARCHAR(100) REFERENCES orders("id") ON DELETE SET NULL,
+    "expires_at" TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE ("user_id", "key")
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,18 @@
+
+-- +migrate Up
+ALTER TABLE products ALTER COLUMN "price" TYPE NUMERIC(19, 2);
+ALTER TABLE products ADD COLUMN "currency" CHAR(3) NOT NULL DEFAULT 'IDR';
+
+ALTER TABLE orders ALTER COLUMN "total_amount" TYPE NUMERIC(19, 2);
+ALTER TABLE orders ADD COLUMN "currency" CHAR(3) NOT NULL DEFAULT 'IDR';
+
+ALTER TABLE order_items ALTER COLUMN "price" TYPE NUMERIC(19, 2);
+
+-- +migrate Down
+ALTER TABLE order_items ALTER COLUMN "price" TYPE DECIMAL;
+
+ALTER TABLE orders DROP COLUMN "currency";
+ALTER TABLE orders ALTER COLUMN "total_amount" TYPE DECIMAL;
+
+ALTER TABLE products DROP COLUMN "currency";
+ALTER TABLE products ALTER COLUMN "price" TYPE DECIMAL;
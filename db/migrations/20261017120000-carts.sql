@@ -0,0 +1,23 @@
+
+-- +migrate Up
+CREATE TABLE carts (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL UNIQUE REFERENCES users("id") ON DELETE CASCADE,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE cart_items (
+    "id" SERIAL PRIMARY KEY,
+    "cart_id" INT NOT NULL REFERENCES carts("id") ON DELETE CASCADE,
+    "product_id" INT NOT NULL REFERENCES products("id") ON DELETE CASCADE,
+    "quantity" INT NOT NULL CHECK ("quantity" > 0),
+    "price" NUMERIC(19, 2) NOT NULL,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE ("cart_id", "product_id")
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS cart_items;
+DROP TABLE IF EXISTS carts;
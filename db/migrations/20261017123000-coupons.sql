@@ -0,0 +1,57 @@
+
+-- +migrate Up
+CREATE TYPE coupon_type AS ENUM ('percentage', 'fixed');
+
+CREATE TABLE coupons (
+    "id" SERIAL PRIMARY KEY,
+    "code" VARCHAR(50) NOT NULL,
+    "type" coupon_type NOT NULL,
+    "percent_off" INT NOT NULL DEFAULT 0 CHECK ("percent_off" BETWEEN 0 AND 100),
+    "amount_off" NUMERIC(19, 2) NOT NULL DEFAULT 0,
+    "currency" VARCHAR(3) NOT NULL DEFAULT '',
+    "max_discount" NUMERIC(19, 2) NOT NULL DEFAULT 0,
+    "min_order_amount" NUMERIC(19, 2) NOT NULL DEFAULT 0,
+    "usage_limit" INT,
+    "per_user_limit" INT,
+    "used_count" INT NOT NULL DEFAULT 0,
+    "starts_at" TIMESTAMP,
+    "ends_at" TIMESTAMP,
+    "product_ids" INT[],
+    "category_ids" INT[],
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" TIMESTAMP
+);
+
+CREATE UNIQUE INDEX coupons_code_key ON coupons ("code") WHERE "deleted_at" IS NULL;
+
+CREATE TABLE coupon_redemptions (
+    "id" SERIAL PRIMARY KEY,
+    "coupon_id" INT NOT NULL REFERENCES coupons("id"),
+    "user_id" INT NOT NULL REFERENCES users("id") ON DELETE CASCADE,
+    "order_id" VARCHAR(100) NOT NULL UNIQUE REFERENCES orders("id") ON DELETE CASCADE,
+    "discount_amount" NUMERIC(19, 2) NOT NULL,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX coupon_redemptions_coupon_user_idx ON coupon_redemptions ("coupon_id", "user_id");
+
+ALTER TABLE orders
+    ADD COLUMN "coupon_id" INT REFERENCES coupons("id") ON DELETE SET NULL,
+    ADD COLUMN "coupon_code" VARCHAR(50),
+    ADD COLUMN "discount_amount" NUMERIC(19, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE order_items
+    ADD COLUMN "discount_amount" NUMERIC(19, 2) NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE order_items DROP COLUMN IF EXISTS "discount_amount";
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS "discount_amount",
+    DROP COLUMN IF EXISTS "coupon_code",
+    DROP COLUMN IF EXISTS "coupon_id";
+
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
+DROP TYPE IF EXISTS coupon_type;
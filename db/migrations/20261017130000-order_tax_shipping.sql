@@ -0,0 +1,16 @@
+
+-- +migrate Up
+ALTER TABLE orders
+    ADD COLUMN "subtotal" NUMERIC(19, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN "tax_amount" NUMERIC(19, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN "shipping_amount" NUMERIC(19, 2) NOT NULL DEFAULT 0;
+
+-- Existing orders carry no tax or shipping, so their subtotal is the
+-- total before the discount.
+UPDATE orders SET "subtotal" = "total_amount" + "discount_amount";
+
+-- +migrate Down
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS "shipping_amount",
+    DROP COLUMN IF EXISTS "tax_amount",
+    DROP COLUMN IF EXISTS "subtotal";
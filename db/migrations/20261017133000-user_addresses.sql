@@ -0,0 +1,34 @@
+
+-- +migrate Up
+CREATE TABLE user_addresses (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL REFERENCES users("id") ON DELETE CASCADE,
+    "label" VARCHAR(50) NOT NULL DEFAULT '',
+    "recipient_name" VARCHAR(255) NOT NULL,
+    "phone" VARCHAR(30) NOT NULL,
+    "line1" VARCHAR(255) NOT NULL,
+    "line2" VARCHAR(255) NOT NULL DEFAULT '',
+    "city" VARCHAR(100) NOT NULL,
+    "province" VARCHAR(100) NOT NULL,
+    "postal_code" VARCHAR(20) NOT NULL,
+    "country_code" CHAR(2) NOT NULL,
+    "is_default" BOOLEAN NOT NULL DEFAULT FALSE,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX user_addresses_user_id_idx ON user_addresses ("user_id") WHERE "deleted_at" IS NULL;
+CREATE UNIQUE INDEX user_addresses_default_key ON user_addresses ("user_id") WHERE "is_default" AND "deleted_at" IS NULL;
+
+-- Orders keep a copy of the addresses used at checkout.
+ALTER TABLE orders
+    ADD COLUMN "shipping_address" JSONB,
+    ADD COLUMN "billing_address" JSONB;
+
+-- +migrate Down
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS "billing_address",
+    DROP COLUMN IF EXISTS "shipping_address";
+
+DROP TABLE IF EXISTS user_addresses;
@@ -0,0 +1,10 @@
+
+-- +migrate Up
+ALTER TABLE products
+    ADD COLUMN "seller_id" INT REFERENCES users("id") ON DELETE SET NULL;
+
+CREATE INDEX products_seller_id_idx ON products ("seller_id");
+
+-- +migrate Down
+DROP INDEX IF EXISTS products_seller_id_idx;
+ALTER TABLE products DROP COLUMN IF EXISTS "seller_id";
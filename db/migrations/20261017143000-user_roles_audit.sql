@@ -0,0 +1,36 @@
+
+-- +migrate Up
+CREATE TABLE user_role_changes (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL REFERENCES users("id") ON DELETE CASCADE,
+    "from_role" roles NOT NULL,
+    "to_role" roles NOT NULL,
+    "actor_user_id" INT REFERENCES users("id") ON DELETE SET NULL,
+    "actor_service" VARCHAR(100) NOT NULL DEFAULT '',
+    "reason" TEXT NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX user_role_changes_user_id_idx ON user_role_changes ("user_id", "created_at");
+
+CREATE TYPE seller_application_status AS ENUM ('pending', 'approved', 'rejected');
+
+CREATE TABLE seller_applications (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL REFERENCES users("id") ON DELETE CASCADE,
+    "store_name" VARCHAR(255) NOT NULL,
+    "note" TEXT NOT NULL DEFAULT '',
+    "status" seller_application_status NOT NULL DEFAULT 'pending',
+    "reviewed_by" INT REFERENCES users("id") ON DELETE SET NULL,
+    "review_note" TEXT NOT NULL DEFAULT '',
+    "reviewed_at" TIMESTAMP,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX seller_applications_pending_key ON seller_applications ("user_id") WHERE "status" = 'pending';
+
+-- +migrate Down
+DROP TABLE IF EXISTS seller_applications;
+DROP TYPE IF EXISTS seller_application_status;
+DROP TABLE IF EXISTS user_role_changes;
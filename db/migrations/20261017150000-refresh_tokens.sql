@@ -0,0 +1,20 @@
+
+-- +migrate Up
+CREATE TABLE refresh_tokens (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL REFERENCES users("id") ON DELETE CASCADE,
+    "session_id" INT NOT NULL REFERENCES user_sessions("id") ON DELETE CASCADE,
+    "token_hash" CHAR(64) NOT NULL UNIQUE,
+    "expires_at" TIMESTAMP NOT NULL,
+    "used_at" TIMESTAMP,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens ("session_id");
+
+-- Every authenticated request now looks its session up by token.
+CREATE INDEX user_sessions_token_idx ON user_sessions USING HASH ("token");
+
+-- +migrate Down
+DROP INDEX IF EXISTS user_sessions_token_idx;
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,15 @@
+
+-- +migrate Up
+ALTER TABLE user_sessions
+    ADD COLUMN "user_agent" TEXT NOT NULL DEFAULT '',
+    ADD COLUMN "ip_address" VARCHAR(45) NOT NULL DEFAULT '',
+    ADD COLUMN "last_seen_at" TIMESTAMP;
+
+CREATE INDEX user_sessions_user_id_idx ON user_sessions ("user_id");
+
+-- +migrate Down
+DROP INDEX IF EXISTS user_sessions_user_id_idx;
+ALTER TABLE user_sessions
+    DROP COLUMN IF EXISTS "last_seen_at",
+    DROP COLUMN IF EXISTS "ip_address",
+    DROP COLUMN IF EXISTS "user_agent";
@@ -0,0 +1,15 @@
+
+-- +migrate Up
+CREATE TABLE password_reset_tokens (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL REFERENCES users("id") ON DELETE CASCADE,
+    "token_hash" CHAR(64) NOT NULL UNIQUE,
+    "expires_at" TIMESTAMP NOT NULL,
+    "used_at" TIMESTAMP,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX password_reset_tokens_user_id_idx ON password_reset_tokens ("user_id");
+
+-- +migrate Down
+DROP TABLE IF EXISTS password_reset_tokens;
@@ -0,0 +1,18 @@
+
+-- +migrate Up
+ALTER TABLE users ADD COLUMN "email_verified_at" TIMESTAMP;
+
+CREATE TABLE email_verification_tokens (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL REFERENCES users("id") ON DELETE CASCADE,
+    "token_hash" CHAR(64) NOT NULL UNIQUE,
+    "expires_at" TIMESTAMP NOT NULL,
+    "used_at" TIMESTAMP,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX email_verification_tokens_user_id_idx ON email_verification_tokens ("user_id", "created_at");
+
+-- +migrate Down
+DROP TABLE IF EXISTS email_verification_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS "email_verified_at";
@@ -0,0 +1,13 @@
+
+-- +migrate Up
+CREATE TABLE login_attempts (
+    "scope" VARCHAR(20) NOT NULL,
+    "key" VARCHAR(255) NOT NULL,
+    "failures" INT NOT NULL DEFAULT 0,
+    "last_failure_at" TIMESTAMP NOT NULL,
+    "locked_until" TIMESTAMP,
+    PRIMARY KEY ("scope", "key")
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS login_attempts;
@@ -0,0 +1,19 @@
+
+-- +migrate Up
+CREATE TABLE api_keys (
+    "id" SERIAL PRIMARY KEY,
+    "owner_id" INT NOT NULL REFERENCES users("id") ON DELETE CASCADE,
+    "name" VARCHAR(100) NOT NULL,
+    "prefix" VARCHAR(20) NOT NULL,
+    "key_hash" CHAR(64) NOT NULL UNIQUE,
+    "scopes" JSONB NOT NULL DEFAULT '[]',
+    "expires_at" TIMESTAMP,
+    "last_used_at" TIMESTAMP,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX api_keys_owner_id_idx ON api_keys ("owner_id");
+
+-- +migrate Down
+DROP TABLE IF EXISTS api_keys;
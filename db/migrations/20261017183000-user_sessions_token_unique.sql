@@ -0,0 +1,14 @@
+
+-- +migrate Up
+-- Tokens issued for the same user in the same second used to be identical.
+-- Keep the newest session of each such group.
+DELETE FROM user_sessions s
+USING user_sessions newer
+WHERE s."token" = newer."token" AND s."id" < newer."id";
+
+DROP INDEX IF EXISTS user_sessions_token_idx;
+CREATE UNIQUE INDEX user_sessions_token_key ON user_sessions ("token");
+
+-- +migrate Down
+DROP INDEX IF EXISTS user_sessions_token_key;
+CREATE INDEX user_sessions_token_idx ON user_sessions USING HASH ("token");
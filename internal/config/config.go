@@ -1,9 +1,14 @@
 package config
 
 import (
+	"encoding/base64"
+	"log"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/spf13/viper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
 )
 
 func ENV() string {
@@ -30,10 +35,237 @@ func GetDbPassword() string {
 	return viper.GetString("postgres.dbpass")
 }
 
-func JWTSigningKey() string {
-	return viper.GetString("jwt.signing_key")
+// JWTKey is a token signing key. Only the active key needs its private
+// key; retired keys are kept, with just a public key if preferred, so
+// tokens they signed keep validating until they expire.
+type JWTKey struct {
+	ID             string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"algorithm"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
+// JWTActiveKeyID is the kid of the key new tokens are signed with.
+func JWTActiveKeyID() string {
+	return viper.GetString("jwt.active_kid")
+}
+
+func JWTKeys() []JWTKey {
+	var keys []JWTKey
+	if err := viper.UnmarshalKey("jwt.keys", &keys); err != nil {
+		log.Fatalf("Invalid jwt.keys: %s", err)
+	}
+	return keys
 }
 
 func JWTExp() time.Duration {
 	return viper.GetDuration("jwt.exp")
 }
+
+// SessionCacheTTL is how long a validated session is trusted without
+// checking user_sessions again. Zero disables the cache.
+func SessionCacheTTL() time.Duration {
+	return viper.GetDuration("session.cache_ttl")
+}
+
+// RefreshTokenTTL is how long a refresh token, and with it the session,
+// stays usable without being rotated.
+func RefreshTokenTTL() time.Duration {
+	return viper.GetDuration("refresh_token.ttl")
+}
+
+func PasswordResetTTL() time.Duration {
+	return viper.GetDuration("password_reset.ttl")
+}
+
+// PasswordResetURL is the page that receives the reset token; the token
+// is appended as the "token" query parameter.
+func PasswordResetURL() string {
+	return viper.GetString("password_reset.url")
+}
+
+func EmailVerificationTTL() time.Duration {
+	return viper.GetDuration("email_verification.ttl")
+}
+
+// EmailVerificationURL is where the verification link points, normally the
+// /v1/auth/verify endpoint; the token is appended as the "token" query
+// parameter.
+func EmailVerificationURL() string {
+	return viper.GetString("email_verification.url")
+}
+
+// EmailVerificationResendInterval is the minimum time between two
+// verification emails for the same user.
+func EmailVerificationResendInterval() time.Duration {
+	return viper.GetDuration("email_verification.resend_interval")
+}
+
+// EmailVerificationRequiredForOrders blocks order creation for users who
+// have not verified their email address.
+func EmailVerificationRequiredForOrders() bool {
+	return viper.GetBool("email_verification.required_for_orders")
+}
+
+func LoginThrottlePolicy() model.LoginThrottlePolicy {
+	return model.LoginThrottlePolicy{
+		FreeAttempts:            viper.GetInt64("login.free_attempts"),
+		BackoffBase:             viper.GetDuration("login.backoff_base"),
+		BackoffMax:              viper.GetDuration("login.backoff_max"),
+		FailureWindow:           viper.GetDuration("login.failure_window"),
+		AccountLockoutThreshold: viper.GetInt64("login.account_lockout_threshold"),
+		IPLockoutThreshold:      viper.GetInt64("login.ip_lockout_threshold"),
+		LockoutDuration:         viper.GetDuration("login.lockout_duration"),
+	}
+}
+
+func TwoFactorPolicy() model.TwoFactorPolicy {
+	var key []byte
+	if encoded := viper.GetString("two_factor.encryption_key"); encoded != "" {
+		var err error
+		key, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			log.Fatalf("two_factor.encryption_key must be 32 bytes in base64")
+		}
+	}
+
+	return model.TwoFactorPolicy{
+		Issuer:               viper.GetString("two_factor.issuer"),
+		EncryptionKey:        key,
+		ChallengeTTL:         viper.GetDuration("two_factor.challenge_ttl"),
+		MaxChallengeAttempts: viper.GetInt64("two_factor.max_challenge_attempts"),
+		RequireForAdmins:     viper.GetBool("two_factor.require_for_admins"),
+	}
+}
+
+// MailerDriver selects the Mailer: "smtp", or "log" for local development.
+func MailerDriver() string {
+	return viper.GetString("mailer.driver")
+}
+
+func MailerFrom() string {
+	return viper.GetString("mailer.from")
+}
+
+func MailerLogDir() string {
+	return viper.GetString("mailer.log.dir")
+}
+
+func SMTPHost() string {
+	return viper.GetString("mailer.smtp.host")
+}
+
+func SMTPPort() int {
+	return viper.GetInt("mailer.smtp.port")
+}
+
+func SMTPUsername() string {
+	return viper.GetString("mailer.smtp.username")
+}
+
+func SMTPPassword() string {
+	return viper.GetString("mailer.smtp.password")
+}
+
+func OrderIDFormat() string {
+	return viper.GetString("order.id.format")
+}
+
+// OrderIDDateLayout is the time layout of the date in order IDs. Sequence
+// numbers restart every calendar day, so the layout has to tell days apart;
+// otherwise two days would issue the same IDs.
+func OrderIDDateLayout() string {
+	layout := viper.GetString("order.id.date_layout")
+
+	// Two years cover every month length and a leap day.
+	seen := make(map[string]bool)
+	day := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 731; i++ {
+		formatted := day.AddDate(0, 0, i).Format(layout)
+		if seen[formatted] {
+			log.Fatalf("Invalid order.id.date_layout %q: it does not tell calendar days apart", layout)
+		}
+		seen[formatted] = true
+	}
+	return layout
+}
+
+func OrderIDTimezone() string {
+	return viper.GetString("order.id.timezone")
+}
+
+func IdempotencyKeyTTL() time.Duration {
+	return viper.GetDuration("idempotency.ttl")
+}
+
+func DefaultCurrency() string {
+	currency := viper.GetString("currency.default")
+	if !model.IsMoneyCurrency(currency) {
+		log.Fatalf("Invalid currency.default %q: only currencies with two decimal places are supported", currency)
+	}
+	return currency
+}
+
+// TaxDefaultRate is the tax rate in basis points for categories without
+// their own rate.
+func TaxDefaultRate() int64 {
+	return viper.GetInt64("tax.default_rate_bps")
+}
+
+// TaxCategoryRates maps category IDs to tax rates in basis points.
+func TaxCategoryRates() map[int64]int64 {
+	rates := make(map[int64]int64)
+	for key := range viper.GetStringMap("tax.category_rates_bps") {
+		categoryID, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid category ID %q in tax.category_rates_bps", key)
+		}
+		rates[categoryID] = viper.GetInt64("tax.category_rates_bps." + key)
+	}
+	return rates
+}
+
+func ShippingTiers() []model.ShippingTier {
+	var raw []struct {
+		MinSubtotal string `mapstructure:"min_subtotal"`
+		Fee         string `mapstructure:"fee"`
+	}
+	if err := viper.UnmarshalKey("shipping.tiers", &raw); err != nil {
+		log.Fatalf("Invalid shipping.tiers: %s", err)
+	}
+
+	tiers := make([]model.ShippingTier, 0, len(raw))
+	for _, tier := range raw {
+		minSubtotal, err := model.ParseMoney(tier.MinSubtotal)
+		if err != nil {
+			log.Fatalf("Invalid shipping tier min_subtotal %q: %s", tier.MinSubtotal, err)
+		}
+		fee, err := model.ParseMoney(tier.Fee)
+		if err != nil {
+			log.Fatalf("Invalid shipping tier fee %q: %s", tier.Fee, err)
+		}
+		tiers = append(tiers, model.ShippingTier{MinSubtotal: minSubtotal, Fee: fee})
+	}
+	return tiers
+}
+
+// ShutdownTimeout bounds how long in-flight requests and queued emails may
+// take to finish once the server is asked to stop.
+func ShutdownTimeout() time.Duration {
+	return viper.GetDuration("server.shutdown_timeout")
+}
+
+// TrustedProxies lists the networks of the reverse proxies in front of the
+// HTTP server. Only their X-Forwarded-For entries are believed when looking
+// for the client IP.
+func TrustedProxies() []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, cidr := range viper.GetStringSlice("server.trusted_proxies") {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Fatalf("Invalid network %q in server.trusted_proxies: %s", cidr, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies
+}
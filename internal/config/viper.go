@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,7 +12,39 @@ func LoadWithViper() {
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
 
+	setDefaults()
+
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatalf("Error reading config file, %s", err)
 	}
 }
+
+func setDefaults() {
+	viper.SetDefault("jwt.exp", 15*time.Minute)
+	viper.SetDefault("session.cache_ttl", 30*time.Second)
+	viper.SetDefault("refresh_token.ttl", 30*24*time.Hour)
+	viper.SetDefault("login.free_attempts", 3)
+	viper.SetDefault("login.backoff_base", time.Second)
+	viper.SetDefault("login.backoff_max", 5*time.Minute)
+	viper.SetDefault("login.failure_window", time.Hour)
+	viper.SetDefault("login.account_lockout_threshold", 10)
+	viper.SetDefault("login.ip_lockout_threshold", 50)
+	viper.SetDefault("login.lockout_duration", 30*time.Minute)
+	viper.SetDefault("two_factor.issuer", "Ecommerce")
+	viper.SetDefault("two_factor.challenge_ttl", 5*time.Minute)
+	viper.SetDefault("two_factor.max_challenge_attempts", 5)
+	viper.SetDefault("two_factor.require_for_admins", false)
+	viper.SetDefault("password_reset.ttl", time.Hour)
+	viper.SetDefault("email_verification.ttl", 24*time.Hour)
+	viper.SetDefault("email_verification.resend_interval", time.Minute)
+	viper.SetDefault("email_verification.required_for_orders", false)
+	viper.SetDefault("mailer.driver", "log")
+	viper.SetDefault("mailer.smtp.port", 587)
+	viper.SetDefault("order.id.format", "ORD-%s-%06d")
+	viper.SetDefault("order.id.date_layout", "20060102")
+	viper.SetDefault("order.id.timezone", "UTC")
+	viper.SetDefault("idempotency.ttl", 24*time.Hour)
+	viper.SetDefault("currency.default", "IDR")
+	viper.SetDefault("tax.default_rate_bps", 0)
+	viper.SetDefault("server.shutdown_timeout", 30*time.Second)
+}
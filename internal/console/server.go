@@ -1,9 +1,17 @@
 package console
 
 import (
+	"context"
+	"errors"
+	"io"
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
@@ -13,6 +21,9 @@ import (
 
 	"github.com/tubagusmf/ecommerce-user-product-service/db"
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/config"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/mailer"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/repository"
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/usecase"
 
@@ -34,86 +45,227 @@ var startServeCmd = &cobra.Command{
 		if err != nil {
 			log.Fatalf("Failed to get SQL DB from Gorm: %v", err)
 		}
-		defer sqlDB.Close()
+
+		jwtKeys, err := helper.JWTKeys()
+		if err != nil {
+			log.Fatalf("Failed to load JWT keys: %v", err)
+		}
 
 		// Setup repositories
 		userRepo := repository.NewUserRepo(dbConn)
 		productRepo := repository.NewProductRepo(dbConn)
 		categoryRepo := repository.NewCategoryRepo(dbConn)
-		orderRepo := repository.NewOrderRepo(dbConn)
+		orderIDLocation, err := time.LoadLocation(config.OrderIDTimezone())
+		if err != nil {
+			log.Fatalf("Invalid order ID timezone: %v", err)
+		}
+		orderIDGenerator := repository.NewDailyOrderIDGenerator(dbConn, config.OrderIDFormat(), config.OrderIDDateLayout(), orderIDLocation)
+		orderRepo := repository.NewOrderRepo(dbConn, orderIDGenerator)
+		idempotencyKeyRepo := repository.NewIdempotencyKeyRepo(dbConn)
+		cartRepo := repository.NewCartRepo(dbConn)
+		couponRepo := repository.NewCouponRepo(dbConn)
+		addressRepo := repository.NewAddressRepo(dbConn)
+		roleRepo := repository.NewRoleRepo(dbConn)
+		loginAttemptRepo := repository.NewLoginAttemptRepo(dbConn)
+		twoFactorRepo := repository.NewTwoFactorRepo(dbConn)
+		apiKeyRepo := repository.NewAPIKeyRepo(dbConn)
 
 		// Setup gRPC connections
 		userConn, err := grpc.Dial("user-service:5001", grpc.WithTransportCredentials(insecure.NewCredentials()))
 		if err != nil {
 			log.Fatalf("Failed to connect to User Service: %v", err)
 		}
-		defer userConn.Close()
 		userClient := userpb.NewUserServiceClient(userConn)
 
 		productConn, err := grpc.Dial("product-service:5002", grpc.WithTransportCredentials(insecure.NewCredentials()))
 		if err != nil {
 			log.Fatalf("Failed to connect to Product Service: %v", err)
 		}
-		defer productConn.Close()
 		productClient := productpb.NewProductServiceClient(productConn)
 
 		orderConn, err := grpc.Dial("order-service:5003", grpc.WithTransportCredentials(insecure.NewCredentials()))
 		if err != nil {
 			log.Fatalf("Failed to connect to Order Service: %v", err)
 		}
-		defer orderConn.Close()
 		orderClient := orderpb.NewOrderServiceClient(orderConn)
 
 		// Setup usecases
-		userUsecase := usecase.NewUserUsecase(userRepo, userClient)
-		productUsecase := usecase.NewProductUsecase(productRepo, productClient)
+		userUsecase := usecase.NewUserUsecase(userRepo, loginAttemptRepo, config.LoginThrottlePolicy(), twoFactorRepo, config.TwoFactorPolicy(), newMailer(), config.SessionCacheTTL(), config.RefreshTokenTTL(), config.PasswordResetTTL(), config.PasswordResetURL(), config.EmailVerificationTTL(), config.EmailVerificationURL(), config.EmailVerificationResendInterval(), userClient)
+		productUsecase := usecase.NewProductUsecase(productRepo, config.DefaultCurrency(), productClient)
 		categoryUsecase := usecase.NewCategoryUsecase(categoryRepo)
-		orderUsecase := usecase.NewOrderUsecase(orderRepo, productRepo, orderClient)
+		taxCalculator := usecase.NewTableTaxCalculator(config.TaxDefaultRate(), config.TaxCategoryRates())
+		shippingRates := usecase.NewTableShippingRateProvider(config.DefaultCurrency(), config.ShippingTiers())
+		orderUsecase := usecase.NewOrderUsecase(orderRepo, productRepo, couponRepo, addressRepo, idempotencyKeyRepo, userRepo, taxCalculator, shippingRates, config.IdempotencyKeyTTL(), config.EmailVerificationRequiredForOrders(), orderClient)
+		cartUsecase := usecase.NewCartUsecase(cartRepo, productRepo, orderUsecase)
+		couponUsecase := usecase.NewCouponUsecase(couponRepo)
+		addressUsecase := usecase.NewAddressUsecase(addressRepo)
+		roleUsecase := usecase.NewRoleUsecase(roleRepo, userRepo, userUsecase)
+		apiKeyUsecase := usecase.NewAPIKeyUsecase(apiKeyRepo, userRepo)
+
+		// Setup HTTP server
+		e := echo.New()
+		e.HTTPErrorHandler = handlerHttp.ErrorHandler
+		e.IPExtractor = ipExtractor(config.TrustedProxies())
+		e.GET("/ping", func(c echo.Context) error {
+			return c.String(http.StatusOK, "pong!")
+		})
+		// Integrations authenticate with API keys on the catalog, order
+		// and coupon routes; account routes need a user session.
+		authMiddleware := handlerHttp.NewAuthMiddleware(userUsecase, nil)
+		integrationAuthMiddleware := handlerHttp.NewAuthMiddleware(userUsecase, apiKeyUsecase)
+		handlerHttp.NewUserHandler(e, userUsecase, authMiddleware)
+		handlerHttp.NewProductHandler(e, productUsecase, integrationAuthMiddleware)
+		handlerHttp.NewCategoryHandler(e, categoryUsecase, integrationAuthMiddleware)
+		handlerHttp.NewOrderHandler(e, orderUsecase, integrationAuthMiddleware)
+		handlerHttp.NewCartHandler(e, cartUsecase, authMiddleware)
+		handlerHttp.NewCouponHandler(e, couponUsecase, integrationAuthMiddleware)
+		handlerHttp.NewAddressHandler(e, addressUsecase, authMiddleware)
+		handlerHttp.NewRoleHandler(e, roleUsecase, authMiddleware)
+		handlerHttp.NewAPIKeyHandler(e, apiKeyUsecase, authMiddleware)
+		handlerHttp.NewJWKSHandler(e, jwtKeys)
+
+		// Setup gRPC server
+		authInterceptor := handlerGrpc.NewAuthInterceptor(userUsecase, apiKeyUsecase)
+		grpcServer := grpc.NewServer(
+			grpc.ChainUnaryInterceptor(handlerGrpc.UnaryErrorInterceptor(), authInterceptor.Unary()),
+			grpc.ChainStreamInterceptor(handlerGrpc.StreamErrorInterceptor(), authInterceptor.Stream()),
+		)
+		grpcUserHandler := handlerGrpc.NewUsergRPCHandler(userUsecase, roleUsecase)
+		grpcOrderHandler := handlerGrpc.NewOrdergRPCHandler(orderUsecase)
+		grpcProductHandler := handlerGrpc.NewProductgRPCHandler(productUsecase)
+		userpb.RegisterUserServiceServer(grpcServer, grpcUserHandler)
+		orderpb.RegisterOrderServiceServer(grpcServer, grpcOrderHandler)
+		productpb.RegisterProductServiceServer(grpcServer, grpcProductHandler)
+
+		lis, err := net.Listen("tcp", ":5001")
+		if err != nil {
+			log.Fatalf("Failed to create gRPC listener: %v", err)
+		}
 
-		quitCh := make(chan bool, 1)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
 		// Start HTTP server
 		go func() {
-			e := echo.New()
-			e.GET("/ping", func(c echo.Context) error {
-				return c.String(http.StatusOK, "pong!")
-			})
-			handlerHttp.NewUserHandler(e, userUsecase)
-			handlerHttp.NewProductHandler(e, productUsecase)
-			handlerHttp.NewCategoryHandler(e, categoryUsecase)
-			handlerHttp.NewOrderHandler(e, orderUsecase)
-
 			log.Println("Starting HTTP server on port 3000...")
-			if err := e.Start(":3000"); err != nil {
+			if err := e.Start(":3000"); err != nil && !errors.Is(err, http.ErrServerClosed) {
 				logrus.Fatalf("Failed to start HTTP server: %v", err)
 			}
 		}()
 
 		// Start gRPC server
 		go func() {
-			grpcServer := grpc.NewServer()
-			grpcUserHandler := handlerGrpc.NewUsergRPCHandler(userUsecase)
-			grpcOrderHandler := handlerGrpc.NewOrdergRPCHandler(orderUsecase)
-			grpcProductHandler := handlerGrpc.NewProductgRPCHandler(productUsecase)
-			userpb.RegisterUserServiceServer(grpcServer, grpcUserHandler)
-			orderpb.RegisterOrderServiceServer(grpcServer, grpcOrderHandler)
-			productpb.RegisterProductServiceServer(grpcServer, grpcProductHandler)
-
-			lis, err := net.Listen("tcp", ":5001")
-			if err != nil {
-				log.Fatalf("Failed to create gRPC listener: %v", err)
-			}
-
 			log.Println("gRPC server is running on port: 5001")
 			if err := grpcServer.Serve(lis); err != nil {
 				log.Fatalf("Failed to start gRPC server: %v", err)
 			}
 		}()
 
-		<-quitCh
+		<-ctx.Done()
+		// A second signal kills the process right away.
+		stop()
+
+		log.Println("Shutting down, waiting for in-flight requests...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout())
+		defer cancel()
+		shutdownServers(shutdownCtx, e, grpcServer)
+
+		// Requests may have queued emails right before they finished.
+		if err := userUsecase.WaitForMail(shutdownCtx); err != nil {
+			log.Printf("Emails still being sent were dropped: %v", err)
+		}
+
+		// The handlers are done with the connections and the pool now.
+		closeAll(
+			namedCloser{"User Service connection", userConn},
+			namedCloser{"Product Service connection", productConn},
+			namedCloser{"Order Service connection", orderConn},
+			namedCloser{"database pool", sqlDB},
+		)
+		log.Println("Shutdown complete")
 	},
 }
 
+// shutdownServers stops both servers from accepting new traffic and gives
+// in-flight HTTP requests and RPCs until ctx is done to finish. Whatever
+// still runs after that is cut off.
+func shutdownServers(ctx context.Context, e *echo.Echo, grpcServer *grpc.Server) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		if err := e.Shutdown(ctx); err != nil {
+			log.Printf("HTTP server did not drain in time: %v", err)
+			e.Close()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			log.Println("gRPC server did not drain in time, cancelling remaining RPCs")
+			grpcServer.Stop()
+		}
+	}()
+
+	wg.Wait()
+}
+
+type namedCloser struct {
+	name   string
+	closer io.Closer
+}
+
+// closeAll closes each resource in order, logging failures so the rest are
+// still closed.
+func closeAll(closers ...namedCloser) {
+	for _, c := range closers {
+		if err := c.closer.Close(); err != nil {
+			log.Printf("Failed to close %s: %v", c.name, err)
+		}
+	}
+}
+
+// ipExtractor reads the client IP from X-Forwarded-For only behind trusted
+// proxies. Without any, the peer address is used, as anyone can send the
+// forwarding headers.
+func ipExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, network := range trustedProxies {
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+func newMailer() model.Mailer {
+	switch config.MailerDriver() {
+	case "smtp":
+		return mailer.NewSMTPMailer(config.SMTPHost(), config.SMTPPort(), config.SMTPUsername(), config.SMTPPassword(), config.MailerFrom())
+	case "log":
+		return mailer.NewLogMailer(config.MailerLogDir(), config.MailerFrom())
+	}
+
+	log.Fatalf("Unknown mailer driver %q", config.MailerDriver())
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(startServeCmd)
 }
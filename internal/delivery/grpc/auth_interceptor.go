@@ -0,0 +1,163 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	orderpb "github.com/tubagusmf/ecommerce-user-product-service/pb/order"
+	productpb "github.com/tubagusmf/ecommerce-user-product-service/pb/product"
+	userpb "github.com/tubagusmf/ecommerce-user-product-service/pb/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadataAPIKey is the metadata key an API key is sent under. gRPC
+// metadata keys are lowercase.
+var metadataAPIKey = strings.ToLower(model.HeaderAPIKey)
+
+// methodPermissions is the permission each RPC requires. An empty permission
+// only requires an authenticated caller; the handler then checks access to
+// the resource itself. RPCs missing from the map are rejected, so new ones
+// have to be added here to be reachable.
+var methodPermissions = map[string]model.Permission{
+	userpb.UserService_GetUser_FullMethodName:        "",
+	userpb.UserService_ChangeUserRole_FullMethodName: model.PermissionUsersManage,
+
+	orderpb.OrderService_CreateOrder_FullMethodName:     model.PermissionOrdersWrite,
+	orderpb.OrderService_GetOrder_FullMethodName:        model.PermissionOrdersRead,
+	orderpb.OrderService_MarkOrderPaid_FullMethodName:   model.PermissionOrdersManage,
+	orderpb.OrderService_ListOrders_FullMethodName:      model.PermissionOrdersRead,
+	orderpb.OrderService_GetOrderHistory_FullMethodName: model.PermissionOrdersRead,
+
+	productpb.ProductService_GetProduct_FullMethodName:    model.PermissionProductsRead,
+	productpb.ProductService_ListProducts_FullMethodName:  model.PermissionProductsRead,
+	productpb.ProductService_CreateProduct_FullMethodName: model.PermissionProductsWrite,
+	productpb.ProductService_UpdateProduct_FullMethodName: model.PermissionProductsWrite,
+	productpb.ProductService_DeleteProduct_FullMethodName: model.PermissionProductsWrite,
+}
+
+// AuthInterceptor authenticates RPCs with a bearer token in the
+// "authorization" metadata or an API key in "x-api-key", checks the
+// permission the method requires and attaches the caller's claims to the
+// context under model.BearerAuthKey.
+type AuthInterceptor struct {
+	userUsecase   model.IUserUsecase
+	apiKeyUsecase model.IAPIKeyUsecase
+}
+
+func NewAuthInterceptor(userUsecase model.IUserUsecase, apiKeyUsecase model.IAPIKeyUsecase) *AuthInterceptor {
+	return &AuthInterceptor{
+		userUsecase:   userUsecase,
+		apiKeyUsecase: apiKeyUsecase,
+	}
+}
+
+func (i *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := i.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (i *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (i *AuthInterceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	permission, ok := methodPermissions[method]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "access denied")
+	}
+
+	claim, err := i.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if permission != "" && !claim.Can(permission) {
+		return nil, status.Error(codes.PermissionDenied, "access denied")
+	}
+
+	return context.WithValue(ctx, model.BearerAuthKey, claim), nil
+}
+
+// authenticate resolves the caller from the request metadata. Bearer tokens
+// must still have a session, so logged out tokens are rejected.
+func (i *AuthInterceptor) authenticate(ctx context.Context) (model.CustomClaims, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if values := md.Get(metadataAPIKey); len(values) > 0 && values[0] != "" {
+		claim, err := i.apiKeyUsecase.Authenticate(ctx, values[0])
+		if err != nil {
+			if errors.Is(err, model.ErrAPIKeyInvalid) {
+				return model.CustomClaims{}, status.Error(codes.Unauthenticated, "invalid API key")
+			}
+			log.Println("Error validating API key:", err)
+			return model.CustomClaims{}, status.Error(codes.Internal, "failed to validate API key")
+		}
+		return claim, nil
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return model.CustomClaims{}, status.Error(codes.Unauthenticated, "missing token")
+	}
+
+	token, found := strings.CutPrefix(values[0], "Bearer ")
+	if !found {
+		return model.CustomClaims{}, status.Error(codes.Unauthenticated, "invalid token format")
+	}
+
+	var claim model.CustomClaims
+	if err := helper.DecodeToken(token, &claim); err != nil || claim.UserID == 0 {
+		return model.CustomClaims{}, status.Error(codes.Unauthenticated, "invalid or expired token")
+	}
+
+	session, err := i.userUsecase.ValidateSession(ctx, token)
+	if err != nil {
+		if errors.Is(err, model.ErrSessionNotFound) {
+			return model.CustomClaims{}, status.Error(codes.Unauthenticated, "session expired or revoked")
+		}
+		log.Println("Error validating session:", err)
+		return model.CustomClaims{}, status.Error(codes.Internal, "failed to validate session")
+	}
+	if session.UserID != claim.UserID {
+		return model.CustomClaims{}, status.Error(codes.Unauthenticated, "invalid or expired token")
+	}
+
+	return claim, nil
+}
+
+// claimsFromContext returns the claims the interceptor attached to ctx.
+func claimsFromContext(ctx context.Context) (model.CustomClaims, error) {
+	claim, ok := ctx.Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return model.CustomClaims{}, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	return claim, nil
+}
+
+// authServerStream carries the authenticated context into stream handlers.
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"context"
+	"log"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain identifies this service in ErrorInfo details.
+const errorDomain = "ecommerce-user-product-service"
+
+// UnaryErrorInterceptor turns domain errors returned by handlers into gRPC
+// statuses, so clients get a proper code instead of codes.Unknown. The
+// machine-readable error code travels as an ErrorInfo detail and failed
+// validation rules as a BadRequest detail, translated according to the
+// "accept-language" metadata. Errors that already carry a status are
+// passed through.
+func UnaryErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, statusError(ctx, info.FullMethod, err)
+		}
+		return resp, nil
+	}
+}
+
+func StreamErrorInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return statusError(ss.Context(), info.FullMethod, err)
+		}
+		return nil
+	}
+}
+
+func statusError(ctx context.Context, method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	domainErr, _, code := helper.ErrorStatus(err)
+	if code == codes.Internal {
+		log.Printf("[ERROR] %s failed: %v", method, err)
+	}
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason: domainErr.Code,
+		Domain: errorDomain,
+	}}
+	if violations := helper.FieldViolations(err, helper.ValidationTranslator(acceptLanguage(ctx))); len(violations) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, violation := range violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       violation.Field,
+				Description: violation.Message,
+			})
+		}
+		details = append(details, badRequest)
+	}
+
+	st := status.New(code, domainErr.Message)
+	detailed, detailErr := st.WithDetails(details...)
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func acceptLanguage(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("accept-language"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
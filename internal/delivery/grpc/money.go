@@ -0,0 +1,17 @@
+package grpc
+
+import (
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	moneypb "github.com/tubagusmf/ecommerce-user-product-service/pb/money"
+)
+
+func convertMoneyToPB(amount model.Money, currency string) *moneypb.Money {
+	return &moneypb.Money{
+		CurrencyCode: currency,
+		AmountMinor:  int64(amount),
+	}
+}
+
+func convertMoneyFromPB(money *moneypb.Money) (model.Money, string) {
+	return model.Money(money.GetAmountMinor()), money.GetCurrencyCode()
+}
@@ -2,12 +2,15 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"log"
 
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
 	pb "github.com/tubagusmf/ecommerce-user-product-service/pb/order"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type OrdergRPCHandler struct {
@@ -20,14 +23,27 @@ func NewOrdergRPCHandler(orderUsecase model.IOrderUsecase) pb.OrderServiceServer
 }
 
 func (h *OrdergRPCHandler) CreateOrder(ctx context.Context, req *pb.CreateOrderRequest) (*pb.CreateOrderResponse, error) {
+	if err := authorizeOrderUser(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+
 	orderInput := model.CreateOrderInput{
-		UserID:     req.UserId,
-		OrderItems: convertOrderItems(req.Items),
+		UserID:            req.UserId,
+		OrderItems:        convertOrderItems(req.Items),
+		CouponCode:        req.CouponCode,
+		ShippingAddressID: req.ShippingAddressId,
+		BillingAddressID:  req.BillingAddressId,
+		IdempotencyKey:    req.IdempotencyKey,
 	}
 
 	createdOrder, err := h.orderUsecase.Create(ctx, orderInput)
 	if err != nil {
 		log.Println("Error creating order:", err)
+		// The coupon and addresses are named by the request, so their absence
+		// is a bad argument rather than a missing resource.
+		if errors.Is(err, model.ErrCouponNotFound) || errors.Is(err, model.ErrAddressNotFound) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, err
 	}
 
@@ -35,9 +51,8 @@ func (h *OrdergRPCHandler) CreateOrder(ctx context.Context, req *pb.CreateOrderR
 }
 
 func (h *OrdergRPCHandler) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
-	order, err := h.orderUsecase.FindById(ctx, req.OrderId)
+	order, err := h.authorizeOrder(ctx, req.OrderId)
 	if err != nil {
-		log.Println("Error fetching order:", err)
 		return nil, err
 	}
 
@@ -45,18 +60,10 @@ func (h *OrdergRPCHandler) GetOrder(ctx context.Context, req *pb.GetOrderRequest
 }
 
 func (h *OrdergRPCHandler) MarkOrderPaid(ctx context.Context, req *pb.MarkOrderPaidRequest) (*pb.MarkOrderPaidResponse, error) {
-	order, err := h.orderUsecase.FindById(ctx, req.OrderId)
-	if err != nil {
-		log.Println("[ERROR] Order not found:", err)
-		return &pb.MarkOrderPaidResponse{Success: false}, status.Errorf(codes.NotFound, "Order not found")
-	}
-
-	order.Status = "success"
-
-	err = h.orderUsecase.Update(ctx, order)
+	err := h.orderUsecase.UpdateOrderStatus(withServiceActor(ctx), req.OrderId, model.OrderStatusPaid, "payment confirmed")
 	if err != nil {
-		log.Println("[ERROR] Failed to update order status:", err)
-		return &pb.MarkOrderPaidResponse{Success: false}, status.Errorf(codes.Internal, "Failed to update order status")
+		log.Println("[ERROR] Failed to mark order as paid:", err)
+		return nil, err
 	}
 
 	log.Printf("[INFO] Order %s marked as PAID", req.OrderId)
@@ -64,6 +71,10 @@ func (h *OrdergRPCHandler) MarkOrderPaid(ctx context.Context, req *pb.MarkOrderP
 }
 
 func (h *OrdergRPCHandler) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	if err := authorizeOrderUser(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+
 	orders, err := h.orderUsecase.ListByUserID(ctx, req.UserId)
 	if err != nil {
 		log.Println("Error fetching orders:", err)
@@ -78,6 +89,77 @@ func (h *OrdergRPCHandler) ListOrders(ctx context.Context, req *pb.ListOrdersReq
 	return &pb.ListOrdersResponse{Orders: pbOrders}, nil
 }
 
+func (h *OrdergRPCHandler) GetOrderHistory(ctx context.Context, req *pb.GetOrderHistoryRequest) (*pb.GetOrderHistoryResponse, error) {
+	if _, err := h.authorizeOrder(ctx, req.OrderId); err != nil {
+		return nil, err
+	}
+
+	history, err := h.orderUsecase.FindStatusHistory(ctx, req.OrderId)
+	if err != nil {
+		log.Println("Error fetching order history:", err)
+		return nil, err
+	}
+
+	pbHistory := make([]*pb.OrderStatusChange, len(history))
+	for i, entry := range history {
+		pbHistory[i] = &pb.OrderStatusChange{
+			ToStatus:     entry.ToStatus,
+			ActorService: entry.ActorService,
+			Reason:       entry.Reason,
+			ChangedAt:    timestamppb.New(entry.CreatedAt),
+		}
+		if entry.FromStatus != nil {
+			pbHistory[i].FromStatus = *entry.FromStatus
+		}
+		if entry.ActorUserID != nil {
+			pbHistory[i].ActorUserId = *entry.ActorUserID
+		}
+	}
+
+	return &pb.GetOrderHistoryResponse{History: pbHistory}, nil
+}
+
+// authorizeOrder fetches the order and checks that the caller may see it.
+func (h *OrdergRPCHandler) authorizeOrder(ctx context.Context, orderID string) (*model.Order, error) {
+	claim, err := claimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := h.orderUsecase.FindById(ctx, orderID)
+	if err != nil {
+		log.Println("Error fetching order:", err)
+		return nil, err
+	}
+
+	if !model.CanAccessOrder(claim, order) {
+		return nil, status.Error(codes.PermissionDenied, "access denied")
+	}
+	return order, nil
+}
+
+// authorizeOrderUser checks that the caller may act on the orders of userID.
+func authorizeOrderUser(ctx context.Context, userID int64) error {
+	claim, err := claimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if userID != claim.UserID && !claim.Can(model.PermissionOrdersManage) {
+		return status.Error(codes.PermissionDenied, "access denied")
+	}
+	return nil
+}
+
+// withServiceActor tags ctx with the invoked RPC so status changes made over
+// gRPC are attributed to the calling service in the order history.
+func withServiceActor(ctx context.Context) context.Context {
+	method, ok := grpc.Method(ctx)
+	if !ok {
+		method = "grpc"
+	}
+	return context.WithValue(ctx, model.ActorServiceKey, method)
+}
+
 func convertOrderItems(items []*pb.OrderItem) []model.CreateOrderItem {
 	var orderItems []model.CreateOrderItem
 	for _, item := range items {
@@ -92,16 +174,47 @@ func convertOrderToPB(order *model.Order) *pb.Order {
 	pbItems := make([]*pb.OrderItem, len(order.OrderItems))
 	for i, item := range order.OrderItems {
 		pbItems[i] = &pb.OrderItem{
-			ProductId: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     item.Price,
+			ProductId:      item.ProductID,
+			Quantity:       item.Quantity,
+			Price:          convertMoneyToPB(item.Price, order.Currency),
+			DiscountAmount: convertMoneyToPB(item.DiscountAmount, order.Currency),
 		}
 	}
 
-	return &pb.Order{
-		OrderId:     order.ID,
-		UserId:      order.UserID,
-		Items:       pbItems,
-		TotalAmount: order.TotalAmount,
+	pbOrder := &pb.Order{
+		OrderId:         order.ID,
+		UserId:          order.UserID,
+		Items:           pbItems,
+		Subtotal:        convertMoneyToPB(order.Subtotal, order.Currency),
+		DiscountAmount:  convertMoneyToPB(order.DiscountAmount, order.Currency),
+		TaxAmount:       convertMoneyToPB(order.TaxAmount, order.Currency),
+		ShippingAmount:  convertMoneyToPB(order.ShippingAmount, order.Currency),
+		TotalAmount:     convertMoneyToPB(order.TotalAmount, order.Currency),
+		ShippingAddress: convertAddressToPB(order.ShippingAddress),
+		BillingAddress:  convertAddressToPB(order.BillingAddress),
+	}
+	if order.CouponCode != nil {
+		pbOrder.CouponCode = *order.CouponCode
+	}
+
+	return pbOrder
+}
+
+func convertAddressToPB(address *model.OrderAddress) *pb.Address {
+	if address == nil {
+		return nil
+	}
+
+	return &pb.Address{
+		AddressId:     address.AddressID,
+		Label:         address.Label,
+		RecipientName: address.RecipientName,
+		Phone:         address.Phone,
+		Line1:         address.Line1,
+		Line2:         address.Line2,
+		City:          address.City,
+		Province:      address.Province,
+		PostalCode:    address.PostalCode,
+		CountryCode:   address.CountryCode,
 	}
 }
@@ -6,6 +6,8 @@ import (
 
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
 	pb "github.com/tubagusmf/ecommerce-user-product-service/pb/product"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type ProductgRPCHandler struct {
@@ -29,7 +31,7 @@ func (h *ProductgRPCHandler) GetProduct(ctx context.Context, req *pb.GetProductR
 			ProductId:   product.ID,
 			Name:        product.Name,
 			Description: product.Description,
-			Price:       product.Price,
+			Price:       convertMoneyToPB(product.Price, product.Currency),
 			Stock:       product.Stock,
 		},
 	}, nil
@@ -48,7 +50,7 @@ func (h *ProductgRPCHandler) ListProducts(ctx context.Context, req *pb.ListProdu
 			ProductId:   product.ID,
 			Name:        product.Name,
 			Description: product.Description,
-			Price:       product.Price,
+			Price:       convertMoneyToPB(product.Price, product.Currency),
 			Stock:       product.Stock,
 		})
 	}
@@ -57,19 +59,31 @@ func (h *ProductgRPCHandler) ListProducts(ctx context.Context, req *pb.ListProdu
 }
 
 func (h *ProductgRPCHandler) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.CreateProductResponse, error) {
+	price, currency := convertMoneyFromPB(req.Price)
 	product := model.Product{
 		Name:        req.Name,
 		Description: req.Description,
-		Price:       req.Price,
+		Price:       price,
+		Currency:    currency,
 		Stock:       req.Stock,
 	}
 
-	createdProduct, err := h.productUsecase.Create(ctx, model.CreateProductInput{
+	claim, err := claimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	input := model.CreateProductInput{
 		Name:        product.Name,
 		Description: product.Description,
 		Price:       product.Price,
+		Currency:    product.Currency,
 		Stock:       product.Stock,
-	})
+	}
+	if claim.Role == model.RoleSeller {
+		input.SellerID = &claim.UserID
+	}
+
+	createdProduct, err := h.productUsecase.Create(ctx, input)
 
 	if err != nil {
 		log.Println("Error creating product:", err)
@@ -81,17 +95,23 @@ func (h *ProductgRPCHandler) CreateProduct(ctx context.Context, req *pb.CreatePr
 			ProductId:   createdProduct.ID,
 			Name:        createdProduct.Name,
 			Description: createdProduct.Description,
-			Price:       createdProduct.Price,
+			Price:       convertMoneyToPB(createdProduct.Price, createdProduct.Currency),
 			Stock:       createdProduct.Stock,
 		},
 	}, nil
 }
 
 func (h *ProductgRPCHandler) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.UpdateProductResponse, error) {
+	if err := h.authorizeProduct(ctx, req.ProductId); err != nil {
+		return nil, err
+	}
+
+	price, currency := convertMoneyFromPB(req.Price)
 	input := model.UpdateProductInput{
 		Name:        req.Name,
 		Description: req.Description,
-		Price:       req.Price,
+		Price:       price,
+		Currency:    currency,
 		Stock:       req.Stock,
 	}
 
@@ -106,13 +126,17 @@ func (h *ProductgRPCHandler) UpdateProduct(ctx context.Context, req *pb.UpdatePr
 			ProductId:   updatedProduct.ID,
 			Name:        updatedProduct.Name,
 			Description: updatedProduct.Description,
-			Price:       updatedProduct.Price,
+			Price:       convertMoneyToPB(updatedProduct.Price, updatedProduct.Currency),
 			Stock:       updatedProduct.Stock,
 		},
 	}, nil
 }
 
 func (h *ProductgRPCHandler) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
+	if err := h.authorizeProduct(ctx, req.ProductId); err != nil {
+		return nil, err
+	}
+
 	err := h.productUsecase.Delete(ctx, req.ProductId)
 	if err != nil {
 		log.Println("Error deleting product:", err)
@@ -121,3 +145,21 @@ func (h *ProductgRPCHandler) DeleteProduct(ctx context.Context, req *pb.DeletePr
 
 	return &pb.DeleteProductResponse{Success: true}, nil
 }
+
+// authorizeProduct checks that the caller may manage the product.
+func (h *ProductgRPCHandler) authorizeProduct(ctx context.Context, id int64) error {
+	claim, err := claimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	product, err := h.productUsecase.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !model.CanManageProduct(claim, product) {
+		return status.Error(codes.PermissionDenied, "access denied")
+	}
+	return nil
+}
@@ -6,21 +6,35 @@ import (
 
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
 	pb "github.com/tubagusmf/ecommerce-user-product-service/pb/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // UsergRPCHandler mengimplementasikan UserServiceServer
 type UsergRPCHandler struct {
 	pb.UnimplementedUserServiceServer
 	userUsecase model.IUserUsecase
+	roleUsecase model.IRoleUsecase
 }
 
 // NewUsergRPCHandler adalah constructor untuk UsergRPCHandler
-func NewUsergRPCHandler(userUsecase model.IUserUsecase) pb.UserServiceServer {
-	return &UsergRPCHandler{userUsecase: userUsecase}
+func NewUsergRPCHandler(userUsecase model.IUserUsecase, roleUsecase model.IRoleUsecase) pb.UserServiceServer {
+	return &UsergRPCHandler{
+		userUsecase: userUsecase,
+		roleUsecase: roleUsecase,
+	}
 }
 
 // GetUser menghandle request GetUser dan mengembalikan data user sesuai dengan protokol gRPC
 func (h *UsergRPCHandler) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	claim, err := claimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !model.CanAccessUser(claim, req.GetUserId()) {
+		return nil, status.Error(codes.PermissionDenied, "access denied")
+	}
+
 	// Ambil data user dari usecase
 	user, err := h.userUsecase.FindById(ctx, req.GetUserId())
 	if err != nil {
@@ -30,12 +44,39 @@ func (h *UsergRPCHandler) GetUser(ctx context.Context, req *pb.GetUserRequest) (
 
 	// Konversi user ke protobuf response
 	response := &pb.GetUserResponse{
-		User: &pb.User{
-			Id:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-		},
+		User: convertUserToPB(user),
 	}
 
 	return response, nil
 }
+
+// ChangeUserRole mengubah role user; hanya untuk admin
+func (h *UsergRPCHandler) ChangeUserRole(ctx context.Context, req *pb.ChangeUserRoleRequest) (*pb.ChangeUserRoleResponse, error) {
+	change, err := h.roleUsecase.ChangeRole(ctx, req.GetUserId(), model.ChangeRoleInput{
+		Role:   req.GetRole(),
+		Reason: req.GetReason(),
+	})
+	if err != nil {
+		log.Println("Error changing user role:", err)
+		return nil, err
+	}
+
+	user, err := h.userUsecase.FindById(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ChangeUserRoleResponse{
+		User:         convertUserToPB(user),
+		PreviousRole: change.FromRole,
+	}, nil
+}
+
+func convertUserToPB(user *model.User) *pb.User {
+	return &pb.User{
+		Id:    user.ID,
+		Name:  user.Name,
+		Email: user.Email,
+		Role:  user.Role,
+	}
+}
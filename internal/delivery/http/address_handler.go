@@ -0,0 +1,157 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+type AddressHandler struct {
+	addressUsecase model.IAddressUsecase
+}
+
+func NewAddressHandler(e *echo.Echo, addressUsecase model.IAddressUsecase, authMiddleware echo.MiddlewareFunc) {
+	handler := &AddressHandler{
+		addressUsecase: addressUsecase,
+	}
+
+	routeAddress := e.Group("v1/users/:id/addresses")
+	routeAddress.GET("", handler.FindAll, authMiddleware)
+	routeAddress.GET("/:address_id", handler.FindById, authMiddleware)
+	routeAddress.POST("", handler.Create, authMiddleware)
+	routeAddress.PUT("/:address_id", handler.Update, authMiddleware)
+	routeAddress.DELETE("/:address_id", handler.Delete, authMiddleware)
+}
+
+func (handler *AddressHandler) FindAll(c echo.Context) error {
+	userID, err := addressOwner(c)
+	if err != nil {
+		return err
+	}
+
+	addresses, err := handler.addressUsecase.FindAllByUserID(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   addresses,
+	})
+}
+
+func (handler *AddressHandler) FindById(c echo.Context) error {
+	userID, err := addressOwner(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseInt(c.Param("address_id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid address ID format")
+	}
+
+	address, err := handler.addressUsecase.FindById(c.Request().Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   address,
+	})
+}
+
+func (handler *AddressHandler) Create(c echo.Context) error {
+	userID, err := addressOwner(c)
+	if err != nil {
+		return err
+	}
+
+	var body model.CreateAddressInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	address, err := handler.addressUsecase.Create(c.Request().Context(), userID, body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, Response{
+		Status:  http.StatusCreated,
+		Message: "Address created successfully",
+		Data:    address,
+	})
+}
+
+func (handler *AddressHandler) Update(c echo.Context) error {
+	userID, err := addressOwner(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseInt(c.Param("address_id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid address ID format")
+	}
+
+	var body model.UpdateAddressInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	address, err := handler.addressUsecase.Update(c.Request().Context(), userID, id, body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Address updated successfully",
+		Data:    address,
+	})
+}
+
+func (handler *AddressHandler) Delete(c echo.Context) error {
+	userID, err := addressOwner(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseInt(c.Param("address_id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid address ID format")
+	}
+
+	if err := handler.addressUsecase.Delete(c.Request().Context(), userID, id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Address deleted successfully",
+	})
+}
+
+// addressOwner returns the user ID from the path, which must be the
+// authenticated user unless the caller manages users.
+func addressOwner(c echo.Context) (int64, error) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID format")
+	}
+
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return 0, echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	if !model.CanAccessUser(claim, userID) {
+		return 0, echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+type APIKeyHandler struct {
+	apiKeyUsecase model.IAPIKeyUsecase
+}
+
+func NewAPIKeyHandler(e *echo.Echo, apiKeyUsecase model.IAPIKeyUsecase, authMiddleware echo.MiddlewareFunc) {
+	handler := &APIKeyHandler{
+		apiKeyUsecase: apiKeyUsecase,
+	}
+
+	routeAPIKey := e.Group("/v1/api-keys", authMiddleware, RequirePermission(model.PermissionUsersManage))
+	routeAPIKey.GET("", handler.FindAll)
+	routeAPIKey.GET("/:id", handler.FindById)
+	routeAPIKey.POST("/create", handler.Create)
+	routeAPIKey.PUT("/update/:id", handler.Update)
+	routeAPIKey.DELETE("/delete/:id", handler.Delete)
+}
+
+func (h *APIKeyHandler) FindAll(c echo.Context) error {
+	var ownerID int64
+	if owner := c.QueryParam("owner_id"); owner != "" {
+		var err error
+		ownerID, err = strconv.ParseInt(owner, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid owner ID format")
+		}
+	}
+
+	keys, err := h.apiKeyUsecase.FindAll(c.Request().Context(), ownerID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   keys,
+	})
+}
+
+func (h *APIKeyHandler) FindById(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+
+	key, err := h.apiKeyUsecase.FindById(c.Request().Context(), id)
+	if err != nil {
+		return apiKeyError(err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   key,
+	})
+}
+
+func (h *APIKeyHandler) Create(c echo.Context) error {
+	var body model.CreateAPIKeyInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	key, plaintext, err := h.apiKeyUsecase.Create(c.Request().Context(), body)
+	if err != nil {
+		return apiKeyError(err)
+	}
+
+	return c.JSON(http.StatusCreated, Response{
+		Status:  http.StatusCreated,
+		Message: "API key created, copy it now as it will not be shown again",
+		Data: struct {
+			*model.APIKey
+			Key string `json:"key"`
+		}{key, plaintext},
+	})
+}
+
+func (h *APIKeyHandler) Update(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+
+	var body model.UpdateAPIKeyInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	key, err := h.apiKeyUsecase.Update(c.Request().Context(), id, body)
+	if err != nil {
+		return apiKeyError(err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "API key updated successfully",
+		Data:    key,
+	})
+}
+
+func (h *APIKeyHandler) Delete(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+
+	if err := h.apiKeyUsecase.Delete(c.Request().Context(), id); err != nil {
+		return apiKeyError(err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "API key deleted successfully",
+	})
+}
+
+// apiKeyError reports a missing key owner, named in the request body, as
+// 422 rather than 404.
+func apiKeyError(err error) error {
+	if errors.Is(err, model.ErrUserNotFound) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "Owner not found").SetInternal(err)
+	}
+	return err
+}
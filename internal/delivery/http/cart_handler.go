@@ -0,0 +1,141 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+type CartHandler struct {
+	cartUsecase model.ICartUsecase
+}
+
+func NewCartHandler(e *echo.Echo, cartUsecase model.ICartUsecase, authMiddleware echo.MiddlewareFunc) {
+	handler := &CartHandler{
+		cartUsecase: cartUsecase,
+	}
+
+	routeCart := e.Group("v1/cart")
+	routeCart.GET("", handler.FindByUserID, authMiddleware)
+	routeCart.POST("/items", handler.AddItem, authMiddleware)
+	routeCart.PUT("/items/:product_id", handler.UpdateItem, authMiddleware)
+	routeCart.DELETE("/items/:product_id", handler.RemoveItem, authMiddleware)
+	routeCart.POST("/checkout", handler.Checkout, authMiddleware, RequirePermission(model.PermissionOrdersWrite))
+}
+
+func (handler *CartHandler) FindByUserID(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	cart, err := handler.cartUsecase.FindByUserID(c.Request().Context(), claim.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   cart,
+	})
+}
+
+func (handler *CartHandler) AddItem(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var body model.AddCartItemInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	cart, err := handler.cartUsecase.AddItem(c.Request().Context(), claim.UserID, body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Item added to cart",
+		Data:    cart,
+	})
+}
+
+func (handler *CartHandler) UpdateItem(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID format")
+	}
+
+	var body model.UpdateCartItemInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	cart, err := handler.cartUsecase.UpdateItem(c.Request().Context(), claim.UserID, productID, body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Cart item updated",
+		Data:    cart,
+	})
+}
+
+func (handler *CartHandler) RemoveItem(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID format")
+	}
+
+	cart, err := handler.cartUsecase.RemoveItem(c.Request().Context(), claim.UserID, productID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Item removed from cart",
+		Data:    cart,
+	})
+}
+
+func (handler *CartHandler) Checkout(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var body model.CheckoutCartInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	body.IdempotencyKey = c.Request().Header.Get(HeaderIdempotencyKey)
+
+	order, err := handler.cartUsecase.Checkout(c.Request().Context(), claim.UserID, body)
+	if err != nil {
+		return referenceError(err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Order created successfully",
+		Data:    order,
+	})
+}
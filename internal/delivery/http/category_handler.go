@@ -12,23 +12,23 @@ type CategoryHandler struct {
 	categoryUsecase model.ICategoryUsecase
 }
 
-func NewCategoryHandler(e *echo.Echo, categoryUsecase model.ICategoryUsecase) {
+func NewCategoryHandler(e *echo.Echo, categoryUsecase model.ICategoryUsecase, authMiddleware echo.MiddlewareFunc) {
 	handler := &CategoryHandler{
 		categoryUsecase: categoryUsecase,
 	}
 
 	routeCategory := e.Group("/v1/categories")
-	routeCategory.GET("", handler.FindAll, AuthMiddleware)
-	routeCategory.GET("/:id", handler.FindById, AuthMiddleware)
-	routeCategory.POST("/create", handler.Create, AuthMiddleware)
-	routeCategory.PUT("/update/:id", handler.Update, AuthMiddleware)
-	routeCategory.DELETE("/delete/:id", handler.Delete, AuthMiddleware)
+	routeCategory.GET("", handler.FindAll, authMiddleware, RequirePermission(model.PermissionCategoriesRead))
+	routeCategory.GET("/:id", handler.FindById, authMiddleware, RequirePermission(model.PermissionCategoriesRead))
+	routeCategory.POST("/create", handler.Create, authMiddleware, RequirePermission(model.PermissionCategoriesWrite))
+	routeCategory.PUT("/update/:id", handler.Update, authMiddleware, RequirePermission(model.PermissionCategoriesWrite))
+	routeCategory.DELETE("/delete/:id", handler.Delete, authMiddleware, RequirePermission(model.PermissionCategoriesWrite))
 }
 
 func (h *CategoryHandler) FindAll(c echo.Context) error {
 	categories, err := h.categoryUsecase.FindAll(c.Request().Context(), model.Category{})
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -46,7 +46,7 @@ func (h *CategoryHandler) FindById(c echo.Context) error {
 
 	category, err := h.categoryUsecase.FindById(c.Request().Context(), id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	if category == nil {
@@ -67,7 +67,7 @@ func (h *CategoryHandler) Create(c echo.Context) error {
 
 	err := h.categoryUsecase.Create(c.Request().Context(), body)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, Response{
@@ -90,10 +90,7 @@ func (h *CategoryHandler) Update(c echo.Context) error {
 
 	err = h.categoryUsecase.Update(c.Request().Context(), id, body)
 	if err != nil {
-		if err.Error() == "category not found" {
-			return echo.NewHTTPError(http.StatusNotFound, "Category not found")
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -111,10 +108,7 @@ func (h *CategoryHandler) Delete(c echo.Context) error {
 
 	err = h.categoryUsecase.Delete(c.Request().Context(), id)
 	if err != nil {
-		if err.Error() == "category not found" {
-			return echo.NewHTTPError(http.StatusNotFound, "Category not found")
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
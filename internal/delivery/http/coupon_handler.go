@@ -0,0 +1,113 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+type CouponHandler struct {
+	couponUsecase model.ICouponUsecase
+}
+
+func NewCouponHandler(e *echo.Echo, couponUsecase model.ICouponUsecase, authMiddleware echo.MiddlewareFunc) {
+	handler := &CouponHandler{
+		couponUsecase: couponUsecase,
+	}
+
+	routeCoupon := e.Group("/v1/coupons", authMiddleware, RequirePermission(model.PermissionCouponsManage))
+	routeCoupon.GET("", handler.FindAll)
+	routeCoupon.GET("/:id", handler.FindById)
+	routeCoupon.POST("/create", handler.Create)
+	routeCoupon.PUT("/update/:id", handler.Update)
+	routeCoupon.DELETE("/delete/:id", handler.Delete)
+}
+
+func (h *CouponHandler) FindAll(c echo.Context) error {
+	coupons, err := h.couponUsecase.FindAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   coupons,
+	})
+}
+
+func (h *CouponHandler) FindById(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+
+	coupon, err := h.couponUsecase.FindById(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   coupon,
+	})
+}
+
+func (h *CouponHandler) Create(c echo.Context) error {
+	var body model.CreateCouponInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	coupon, err := h.couponUsecase.Create(c.Request().Context(), body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, Response{
+		Status:  http.StatusCreated,
+		Message: "Coupon created successfully",
+		Data:    coupon,
+	})
+}
+
+func (h *CouponHandler) Update(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+
+	var body model.UpdateCouponInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	coupon, err := h.couponUsecase.Update(c.Request().Context(), id, body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Coupon updated successfully",
+		Data:    coupon,
+	})
+}
+
+func (h *CouponHandler) Delete(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+
+	err = h.couponUsecase.Delete(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Coupon deleted successfully",
+	})
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+// ErrorResponse is the body of every error response. Code is stable and
+// meant for programs; Message is meant for people. Validation errors list
+// the failed fields in Details, in the language asked for by
+// Accept-Language.
+type ErrorResponse struct {
+	Status  int                    `json:"status"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details []model.FieldViolation `json:"details,omitempty"`
+}
+
+const HeaderAcceptLanguage = "Accept-Language"
+
+// ErrorHandler renders errors returned by handlers and middleware. Domain
+// errors get the status their kind maps to. An *echo.HTTPError keeps its
+// status, and its code comes from the domain error set with SetInternal or,
+// failing that, from the status text.
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	body := errorResponse(err)
+	body.Details = helper.FieldViolations(err, helper.ValidationTranslator(c.Request().Header.Get(HeaderAcceptLanguage)))
+	if body.Status >= http.StatusInternalServerError {
+		logrus.WithFields(logrus.Fields{
+			"method": c.Request().Method,
+			"path":   c.Path(),
+		}).Error("Request failed: ", err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(body.Status)
+	} else {
+		err = c.JSON(body.Status, body)
+	}
+	if err != nil {
+		logrus.Error("Failed to write error response: ", err)
+	}
+}
+
+func errorResponse(err error) ErrorResponse {
+	var httpErr *echo.HTTPError
+	if !errors.As(err, &httpErr) {
+		domainErr, status, _ := helper.ErrorStatus(err)
+		return ErrorResponse{Status: status, Code: domainErr.Code, Message: domainErr.Message}
+	}
+
+	body := ErrorResponse{
+		Status:  httpErr.Code,
+		Code:    statusCode(httpErr.Code),
+		Message: fmt.Sprint(httpErr.Message),
+	}
+	var domainErr *model.Error
+	if errors.As(httpErr.Internal, &domainErr) {
+		body.Code = domainErr.Code
+	}
+	return body
+}
+
+// referenceError reports a coupon or address named in the request body that
+// does not exist as 422 rather than 404, which would suggest the route
+// itself was not found.
+func referenceError(err error) error {
+	if errors.Is(err, model.ErrCouponNotFound) || errors.Is(err, model.ErrAddressNotFound) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error()).SetInternal(err)
+	}
+	return err
+}
+
+// statusCode derives a machine-readable code from an HTTP status, e.g.
+// "not_found" for 404.
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
@@ -0,0 +1,19 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+)
+
+// NewJWKSHandler publishes the token verification keys so other services
+// can validate access tokens without sharing a secret.
+func NewJWKSHandler(e *echo.Echo, keys *helper.JWTKeySet) {
+	jwks := keys.JWKS()
+
+	e.GET("/.well-known/jwks.json", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+		return c.JSON(http.StatusOK, jwks)
+	})
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
@@ -12,31 +13,109 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		authHeader := c.Request().Header.Get(echo.HeaderAuthorization)
-		if authHeader == "" {
-			return echo.NewHTTPError(http.StatusUnauthorized, "Missing token")
-		}
+// NewAuthMiddleware authenticates requests with a bearer token. The token
+// must decode and still have a session in user_sessions, so logged out
+// tokens are rejected. When apiKeyUsecase is set, an X-API-Key header is
+// accepted instead; leave it nil on routes API keys must not reach.
+func NewAuthMiddleware(userUsecase model.IUserUsecase, apiKeyUsecase model.IAPIKeyUsecase) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if apiKey := c.Request().Header.Get(model.HeaderAPIKey); apiKey != "" && apiKeyUsecase != nil {
+				claim, err := apiKeyUsecase.Authenticate(c.Request().Context(), apiKey)
+				if err != nil {
+					if errors.Is(err, model.ErrAPIKeyInvalid) {
+						return echo.NewHTTPError(http.StatusUnauthorized, "Invalid API key")
+					}
+					return echo.NewHTTPError(http.StatusInternalServerError, "Failed to validate API key")
+				}
+
+				ctx := context.WithValue(c.Request().Context(), model.BearerAuthKey, claim)
+				c.SetRequest(c.Request().WithContext(ctx))
+				return next(c)
+			}
+
+			authHeader := c.Request().Header.Get(echo.HeaderAuthorization)
+			if authHeader == "" {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Missing token")
+			}
+
+			accessToken, ok := bearerToken(authHeader)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token format")
+			}
 
-		splitAuth := strings.Split(authHeader, " ")
-		if len(splitAuth) != 2 || splitAuth[0] != "Bearer" {
-			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token format")
+			var claim model.CustomClaims
+			err := helper.DecodeToken(accessToken, &claim)
+			if err != nil || claim.UserID == 0 {
+				log.Println("Token decoding failed:", err)
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired token")
+			}
+
+			session, err := userUsecase.ValidateSession(c.Request().Context(), accessToken)
+			if err != nil {
+				if errors.Is(err, model.ErrSessionNotFound) {
+					return echo.NewHTTPError(http.StatusUnauthorized, "Session expired or revoked")
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to validate session")
+			}
+			if session.UserID != claim.UserID {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired token")
+			}
+
+			ctx := context.WithValue(c.Request().Context(), model.BearerAuthKey, claim)
+			req := c.Request().WithContext(ctx)
+			c.SetRequest(req)
+
+			return next(c)
 		}
+	}
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>"
+// header value.
+func bearerToken(authHeader string) (string, bool) {
+	splitAuth := strings.Split(authHeader, " ")
+	if len(splitAuth) != 2 || splitAuth[0] != "Bearer" {
+		return "", false
+	}
+	return splitAuth[1], true
+}
 
-		accessToken := splitAuth[1]
+// RequireRole rejects callers whose role is not one of roles. It must run
+// after the auth middleware.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+			}
 
-		var claim model.CustomClaims
-		err := helper.DecodeToken(accessToken, &claim)
-		if err != nil {
-			log.Println("Token decoding failed:", err)
-			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired token")
+			for _, role := range roles {
+				if claim.Role == role {
+					return next(c)
+				}
+			}
+
+			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
 		}
+	}
+}
 
-		ctx := context.WithValue(c.Request().Context(), model.BearerAuthKey, claim)
-		req := c.Request().WithContext(ctx)
-		c.SetRequest(req)
+// RequirePermission allows the roles granted permission by the access
+// policy; API key callers also need it among the key's scopes.
+func RequirePermission(permission model.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+			}
 
-		return next(c)
+			if !claim.Can(permission) {
+				return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+			}
+			return next(c)
+		}
 	}
 }
@@ -8,37 +8,50 @@ import (
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
 )
 
+const HeaderIdempotencyKey = "Idempotency-Key"
+
 type OrderHandler struct {
 	orderUsecase model.IOrderUsecase
 }
 
-func NewOrderHandler(e *echo.Echo, orderUsecase model.IOrderUsecase) {
+func NewOrderHandler(e *echo.Echo, orderUsecase model.IOrderUsecase, authMiddleware echo.MiddlewareFunc) {
 	handler := &OrderHandler{
 		orderUsecase: orderUsecase,
 	}
 
 	routeOrder := e.Group("v1/orders")
-	routeOrder.GET("", handler.FindAll, AuthMiddleware)
-	routeOrder.GET("/:id", handler.FindById, AuthMiddleware)
-	routeOrder.POST("/create", handler.Create, AuthMiddleware)
-	routeOrder.DELETE("/delete/:id", handler.Delete, AuthMiddleware)
+	routeOrder.GET("", handler.FindAll, authMiddleware, RequirePermission(model.PermissionOrdersRead))
+	routeOrder.GET("/:id", handler.FindById, authMiddleware, RequirePermission(model.PermissionOrdersRead))
+	routeOrder.GET("/:id/history", handler.FindStatusHistory, authMiddleware, RequirePermission(model.PermissionOrdersRead))
+	routeOrder.POST("/create", handler.Create, authMiddleware, RequirePermission(model.PermissionOrdersWrite))
+	routeOrder.POST("/cancel/:id", handler.Cancel, authMiddleware, RequirePermission(model.PermissionOrdersWrite))
+	routeOrder.POST("/refund/:id", handler.Refund, authMiddleware, RequirePermission(model.PermissionOrdersManage))
+	routeOrder.DELETE("/delete/:id", handler.Delete, authMiddleware, RequirePermission(model.PermissionOrdersManage))
 }
 
 func (handler *OrderHandler) FindAll(c echo.Context) error {
-	userIDStr := c.QueryParam("user_id")
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
 
-	if userIDStr == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "user_id is required")
+	// Defaults to the caller's own orders.
+	userID := claim.UserID
+	if userIDStr := c.QueryParam("user_id"); userIDStr != "" {
+		var err error
+		userID, err = strconv.ParseInt(userIDStr, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID format")
+		}
 	}
 
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID format")
+	if userID != claim.UserID && !claim.Can(model.PermissionOrdersManage) {
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
 	}
 
 	orders, err := handler.orderUsecase.FindAll(c.Request().Context(), userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -54,12 +67,18 @@ func (handler *OrderHandler) FindById(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Order ID is required")
 	}
 
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
 	order, err := handler.orderUsecase.FindById(c.Request().Context(), id)
 	if err != nil {
-		if err.Error() == "order not found" {
-			return echo.NewHTTPError(http.StatusNotFound, "Order not found")
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	if !model.CanAccessOrder(claim, order) {
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -68,15 +87,54 @@ func (handler *OrderHandler) FindById(c echo.Context) error {
 	})
 }
 
+func (handler *OrderHandler) FindStatusHistory(c echo.Context) error {
+	id := c.Param("id")
+
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	order, err := handler.orderUsecase.FindById(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if !model.CanAccessOrder(claim, order) {
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	history, err := handler.orderUsecase.FindStatusHistory(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   history,
+	})
+}
+
 func (handler *OrderHandler) Create(c echo.Context) error {
 	var body model.CreateOrderInput
 	if err := c.Bind(&body); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
+	body.IdempotencyKey = c.Request().Header.Get(HeaderIdempotencyKey)
+
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Only admins may place orders on behalf of another user.
+	if body.UserID == 0 || !claim.Can(model.PermissionOrdersManage) {
+		body.UserID = claim.UserID
+	}
 
 	createOrder, err := handler.orderUsecase.Create(c.Request().Context(), body)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return referenceError(err)
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -86,12 +144,64 @@ func (handler *OrderHandler) Create(c echo.Context) error {
 	})
 }
 
+func (handler *OrderHandler) Cancel(c echo.Context) error {
+	id := c.Param("id")
+
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	order, err := handler.orderUsecase.FindById(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if !model.CanAccessOrder(claim, order) {
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	var body model.CancelOrderInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	err = handler.orderUsecase.Cancel(c.Request().Context(), id, body.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Order cancelled successfully",
+	})
+}
+
+func (handler *OrderHandler) Refund(c echo.Context) error {
+	id := c.Param("id")
+
+	var body model.RefundOrderInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	err := handler.orderUsecase.Refund(c.Request().Context(), id, body.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Order refunded successfully",
+	})
+}
+
 func (handler *OrderHandler) Delete(c echo.Context) error {
 	id := c.Param("id")
 
 	err := handler.orderUsecase.Delete(c.Request().Context(), id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -12,17 +12,17 @@ type ProductHandler struct {
 	productUsecase model.IProductUsecase
 }
 
-func NewProductHandler(e *echo.Echo, productUsecase model.IProductUsecase) {
+func NewProductHandler(e *echo.Echo, productUsecase model.IProductUsecase, authMiddleware echo.MiddlewareFunc) {
 	handler := &ProductHandler{
 		productUsecase: productUsecase,
 	}
 
 	routeProduct := e.Group("v1/products")
-	routeProduct.GET("", handler.FindAll, AuthMiddleware)
-	routeProduct.GET("/:id", handler.FindById, AuthMiddleware)
-	routeProduct.POST("/create", handler.Create, AuthMiddleware)
-	routeProduct.PUT("/update/:id", handler.Update, AuthMiddleware)
-	routeProduct.DELETE("/delete/:id", handler.Delete, AuthMiddleware)
+	routeProduct.GET("", handler.FindAll, authMiddleware, RequirePermission(model.PermissionProductsRead))
+	routeProduct.GET("/:id", handler.FindById, authMiddleware, RequirePermission(model.PermissionProductsRead))
+	routeProduct.POST("/create", handler.Create, authMiddleware, RequirePermission(model.PermissionProductsWrite))
+	routeProduct.PUT("/update/:id", handler.Update, authMiddleware, RequirePermission(model.PermissionProductsWrite))
+	routeProduct.DELETE("/delete/:id", handler.Delete, authMiddleware, RequirePermission(model.PermissionProductsWrite))
 }
 
 func (handler *ProductHandler) FindAll(c echo.Context) error {
@@ -34,7 +34,7 @@ func (handler *ProductHandler) FindAll(c echo.Context) error {
 
 	products, err := handler.productUsecase.FindAll(c.Request().Context(), filter)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -52,10 +52,7 @@ func (handler *ProductHandler) FindById(c echo.Context) error {
 
 	product, err := handler.productUsecase.FindById(c.Request().Context(), id)
 	if err != nil {
-		if err.Error() == "product not found" {
-			return echo.NewHTTPError(http.StatusNotFound, "Product not found")
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -70,9 +67,17 @@ func (handler *ProductHandler) Create(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+	if claim.Role == model.RoleSeller {
+		body.SellerID = &claim.UserID
+	}
+
 	createdProduct, err := handler.productUsecase.Create(c.Request().Context(), body)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, Response{
@@ -94,12 +99,13 @@ func (handler *ProductHandler) Update(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	if err := handler.authorizeProduct(c, id); err != nil {
+		return err
+	}
+
 	updateProduct, err := handler.productUsecase.Update(c.Request().Context(), id, body)
 	if err != nil {
-		if err.Error() == "product not found" {
-			return echo.NewHTTPError(http.StatusNotFound, "Product not found")
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -116,9 +122,13 @@ func (handler *ProductHandler) Delete(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
 	}
 
+	if err := handler.authorizeProduct(c, id); err != nil {
+		return err
+	}
+
 	err = handler.productUsecase.Delete(c.Request().Context(), id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -126,3 +136,21 @@ func (handler *ProductHandler) Delete(c echo.Context) error {
 		Message: "Product deleted successfully",
 	})
 }
+
+// authorizeProduct checks that the caller may manage the product.
+func (handler *ProductHandler) authorizeProduct(c echo.Context, id int64) error {
+	product, err := handler.productUsecase.FindById(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+	if !model.CanManageProduct(claim, product) {
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+type RoleHandler struct {
+	roleUsecase model.IRoleUsecase
+}
+
+func NewRoleHandler(e *echo.Echo, roleUsecase model.IRoleUsecase, authMiddleware echo.MiddlewareFunc) {
+	handler := &RoleHandler{
+		roleUsecase: roleUsecase,
+	}
+
+	routeRole := e.Group("v1/auth")
+	routeRole.PUT("/users/:id/role", handler.ChangeRole, authMiddleware, RequirePermission(model.PermissionUsersManage))
+	routeRole.GET("/users/:id/role-changes", handler.FindRoleChanges, authMiddleware, RequirePermission(model.PermissionUsersManage))
+	routeRole.POST("/seller-applications", handler.ApplyForSeller, authMiddleware)
+	routeRole.GET("/seller-applications", handler.FindSellerApplications, authMiddleware, RequirePermission(model.PermissionUsersManage))
+	routeRole.POST("/seller-applications/:id/approve", handler.ApproveSellerApplication, authMiddleware, RequirePermission(model.PermissionUsersManage))
+	routeRole.POST("/seller-applications/:id/reject", handler.RejectSellerApplication, authMiddleware, RequirePermission(model.PermissionUsersManage))
+}
+
+func (handler *RoleHandler) ChangeRole(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID format")
+	}
+
+	var body model.ChangeRoleInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	change, err := handler.roleUsecase.ChangeRole(c.Request().Context(), id, body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "User role updated successfully",
+		Data:    change,
+	})
+}
+
+func (handler *RoleHandler) FindRoleChanges(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID format")
+	}
+
+	changes, err := handler.roleUsecase.FindRoleChanges(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   changes,
+	})
+}
+
+func (handler *RoleHandler) ApplyForSeller(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var body model.SellerApplicationInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	application, err := handler.roleUsecase.ApplyForSeller(c.Request().Context(), claim.UserID, body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, Response{
+		Status:  http.StatusCreated,
+		Message: "Seller application submitted",
+		Data:    application,
+	})
+}
+
+func (handler *RoleHandler) FindSellerApplications(c echo.Context) error {
+	applications, err := handler.roleUsecase.FindSellerApplications(c.Request().Context(), c.QueryParam("status"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   applications,
+	})
+}
+
+func (handler *RoleHandler) ApproveSellerApplication(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+
+	var body model.ReviewSellerApplicationInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	application, err := handler.roleUsecase.ApproveSellerApplication(c.Request().Context(), id, body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Seller application approved",
+		Data:    application,
+	})
+}
+
+func (handler *RoleHandler) RejectSellerApplication(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+
+	var body model.ReviewSellerApplicationInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	application, err := handler.roleUsecase.RejectSellerApplication(c.Request().Context(), id, body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Seller application rejected",
+		Data:    application,
+	})
+}
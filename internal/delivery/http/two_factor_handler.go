@@ -0,0 +1,143 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+// loginResponse answers a login step with either the session tokens or the
+// two-factor challenge still to be completed.
+func loginResponse(c echo.Context, result *model.LoginResult) error {
+	if result.Challenge != nil {
+		message := "Two-factor authentication required"
+		if result.Challenge.EnrollmentRequired {
+			message = "Two-factor enrollment required"
+		}
+		return c.JSON(http.StatusOK, Response{
+			Status:  http.StatusOK,
+			Message: message,
+			Data:    result.Challenge,
+		})
+	}
+
+	response := Response{
+		Status:       http.StatusOK,
+		Message:      "Login successful",
+		AccessToken:  result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
+	}
+	if len(result.RecoveryCodes) > 0 {
+		response.Data = map[string][]string{"recovery_codes": result.RecoveryCodes}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (handler *UserHandler) CompleteLogin(c echo.Context) error {
+	var body model.TwoFactorLoginInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	body.UserAgent = c.Request().UserAgent()
+	body.IPAddress = c.RealIP()
+
+	result, err := handler.userUsecase.CompleteLogin(c.Request().Context(), body)
+	if err != nil {
+		return codeError(c, err)
+	}
+
+	return loginResponse(c, result)
+}
+
+func (handler *UserHandler) EnrollTOTPWithChallenge(c echo.Context) error {
+	var body model.TwoFactorChallengeInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	enrollment, err := handler.userUsecase.EnrollTOTPWithChallenge(c.Request().Context(), body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Scan the code with an authenticator app, then log in with a code from it",
+		Data:    enrollment,
+	})
+}
+
+func (handler *UserHandler) EnrollTOTP(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	enrollment, err := handler.userUsecase.EnrollTOTP(c.Request().Context(), claim.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Scan the code with an authenticator app, then confirm with a code from it",
+		Data:    enrollment,
+	})
+}
+
+func (handler *UserHandler) ConfirmTOTP(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var body model.TwoFactorCodeInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	recoveryCodes, err := handler.userUsecase.ConfirmTOTP(c.Request().Context(), claim.UserID, body)
+	if err != nil {
+		return codeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Two-factor authentication enabled, store the recovery codes safely",
+		Data:    map[string][]string{"recovery_codes": recoveryCodes},
+	})
+}
+
+func (handler *UserHandler) DisableTOTP(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var body model.TwoFactorCodeInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	err := handler.userUsecase.DisableTOTP(c.Request().Context(), claim.UserID, body)
+	if err != nil {
+		return codeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Two-factor authentication disabled",
+	})
+}
+
+// codeError tells clients throttled for guessing two-factor codes when to
+// try again.
+func codeError(c echo.Context, err error) error {
+	var throttled *model.LoginThrottledError
+	if errors.As(err, &throttled) {
+		setRetryAfter(c, throttled)
+	}
+	return err
+}
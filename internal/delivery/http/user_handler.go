@@ -1,12 +1,15 @@
 package http
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 )
 
@@ -14,19 +17,37 @@ type UserHandler struct {
 	userUsecase model.IUserUsecase
 }
 
-func NewUserHandler(e *echo.Echo, userUsecase model.IUserUsecase) {
+func NewUserHandler(e *echo.Echo, userUsecase model.IUserUsecase, authMiddleware echo.MiddlewareFunc) {
 	handlers := &UserHandler{
 		userUsecase: userUsecase,
 	}
 
 	routeUser := e.Group("v1/auth")
 	routeUser.POST("/login", handlers.Login)
-	routeUser.POST("/logout", handlers.Logout, AuthMiddleware)
-	routeUser.GET("/user/:id", handlers.FindById, AuthMiddleware)
-	routeUser.GET("/users", handlers.FindAll, AuthMiddleware)
+	routeUser.POST("/login/2fa", handlers.CompleteLogin)
+	routeUser.POST("/login/2fa/enroll", handlers.EnrollTOTPWithChallenge)
+	routeUser.POST("/refresh", handlers.Refresh)
+	routeUser.POST("/logout", handlers.Logout, authMiddleware)
+	routeUser.POST("/password/forgot", handlers.ForgotPassword)
+	routeUser.POST("/password/reset", handlers.ResetPassword)
+	routeUser.GET("/verify", handlers.VerifyEmail)
+	routeUser.POST("/verify/resend", handlers.ResendVerificationEmail, authMiddleware)
+	routeUser.GET("/user/:id", handlers.FindById, authMiddleware)
+	routeUser.GET("/users", handlers.FindAll, authMiddleware, RequirePermission(model.PermissionUsersManage))
+	routeUser.POST("/users/:id/unlock", handlers.UnlockAccount, authMiddleware, RequirePermission(model.PermissionUsersManage))
 	routeUser.POST("/register", handlers.Create)
-	routeUser.PUT("/update/:id", handlers.Update, AuthMiddleware)
-	routeUser.DELETE("/delete/:id", handlers.Delete, AuthMiddleware)
+	routeUser.PUT("/update/:id", handlers.Update, authMiddleware)
+	routeUser.DELETE("/delete/:id", handlers.Delete, authMiddleware)
+
+	routeUser.POST("/2fa/enroll", handlers.EnrollTOTP, authMiddleware)
+	routeUser.POST("/2fa/confirm", handlers.ConfirmTOTP, authMiddleware)
+	routeUser.POST("/2fa/disable", handlers.DisableTOTP, authMiddleware)
+
+	routeUser.GET("/sessions", handlers.ListSessions, authMiddleware)
+	routeUser.DELETE("/sessions/:id", handlers.RevokeSession, authMiddleware)
+	routeUser.POST("/sessions/revoke-others", handlers.RevokeOtherSessions, authMiddleware)
+	routeUser.GET("/users/:id/sessions", handlers.ListUserSessions, authMiddleware, RequirePermission(model.PermissionUsersManage))
+	routeUser.DELETE("/users/:id/sessions", handlers.RevokeUserSessions, authMiddleware, RequirePermission(model.PermissionUsersManage))
 }
 
 // Login handler untuk autentikasi user
@@ -35,28 +56,58 @@ func (handler *UserHandler) Login(c echo.Context) error {
 	if err := c.Bind(&body); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
+	body.UserAgent = c.Request().UserAgent()
+	body.IPAddress = c.RealIP()
 
-	accessToken, err := handler.userUsecase.Login(c.Request().Context(), body)
+	result, err := handler.userUsecase.Login(c.Request().Context(), body)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid Email or Password")
+		var throttled *model.LoginThrottledError
+		var validationErrs validator.ValidationErrors
+		switch {
+		case errors.As(err, &throttled):
+			setRetryAfter(c, throttled)
+		case errors.Is(err, model.ErrInvalidCredentials), errors.As(err, &validationErrs):
+			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid Email or Password").SetInternal(model.ErrInvalidCredentials)
+		}
+		return err
+	}
+
+	return loginResponse(c, result)
+}
+
+func setRetryAfter(c echo.Context, throttled *model.LoginThrottledError) {
+	retryAfter := int64(throttled.RetryAfter.Round(time.Second) / time.Second)
+	c.Response().Header().Set(echo.HeaderRetryAfter, strconv.FormatInt(max(retryAfter, 1), 10))
+}
+
+func (handler *UserHandler) Refresh(c echo.Context) error {
+	var body model.RefreshTokenInput
+	if err := c.Bind(&body); err != nil || body.RefreshToken == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	tokens, err := handler.userUsecase.Refresh(c.Request().Context(), body.RefreshToken)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
-		Status:      http.StatusOK,
-		Message:     "Login successful",
-		AccessToken: accessToken,
+		Status:       http.StatusOK,
+		Message:      "Token refreshed",
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	})
 }
 
 func (handler *UserHandler) Logout(c echo.Context) error {
-	token := c.Request().Header.Get("Authorization")
-	if token == "" {
+	token, ok := bearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+	if !ok {
 		return echo.NewHTTPError(http.StatusBadRequest, "Missing token")
 	}
 
 	err := handler.userUsecase.Logout(c.Request().Context(), token)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to logout")
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -65,6 +116,86 @@ func (handler *UserHandler) Logout(c echo.Context) error {
 	})
 }
 
+func (handler *UserHandler) ForgotPassword(c echo.Context) error {
+	var body model.ForgotPasswordInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	err := handler.userUsecase.ForgotPassword(c.Request().Context(), body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "If the email is registered, a password reset link has been sent",
+	})
+}
+
+func (handler *UserHandler) ResetPassword(c echo.Context) error {
+	var body model.ResetPasswordInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	err := handler.userUsecase.ResetPassword(c.Request().Context(), body)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Password has been reset, please log in again",
+	})
+}
+
+func (handler *UserHandler) VerifyEmail(c echo.Context) error {
+	err := handler.userUsecase.VerifyEmail(c.Request().Context(), c.QueryParam("token"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Email verified successfully",
+	})
+}
+
+func (handler *UserHandler) ResendVerificationEmail(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	err := handler.userUsecase.ResendVerificationEmail(c.Request().Context(), claim.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Verification email sent",
+	})
+}
+
+func (handler *UserHandler) UnlockAccount(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID format")
+	}
+
+	err = handler.userUsecase.UnlockAccount(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Account unlocked",
+	})
+}
+
 func (handler *UserHandler) FindById(c echo.Context) error {
 	idParam := c.Param("id")
 	id, err := strconv.ParseInt(idParam, 10, 64)
@@ -77,7 +208,7 @@ func (handler *UserHandler) FindById(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
 	}
 
-	if claim.UserID != id {
+	if !model.CanAccessUser(claim, id) {
 		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
 	}
 
@@ -85,7 +216,7 @@ func (handler *UserHandler) FindById(c echo.Context) error {
 
 	user, err := handler.userUsecase.FindById(c.Request().Context(), id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, "User not found")
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -101,7 +232,7 @@ func (handler *UserHandler) FindAll(c echo.Context) error {
 
 	users, err := handler.userUsecase.FindAll(c.Request().Context(), filter)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch users")
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -115,20 +246,23 @@ func (handler *UserHandler) Create(c echo.Context) error {
 	if err := c.Bind(&body); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
+	body.UserAgent = c.Request().UserAgent()
+	body.IPAddress = c.RealIP()
 
 	if body.Name == "" || body.Email == "" || body.Password == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "All fields are required")
 	}
 
-	accessToken, err := handler.userUsecase.Create(c.Request().Context(), body)
+	tokens, err := handler.userUsecase.Create(c.Request().Context(), body)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create user")
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, Response{
-		Status:      http.StatusCreated,
-		Message:     "User registered successfully",
-		AccessToken: accessToken,
+		Status:       http.StatusCreated,
+		Message:      "User registered successfully",
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	})
 }
 
@@ -144,8 +278,8 @@ func (handler *UserHandler) Update(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
 	}
 
-	// User hanya boleh mengupdate datanya sendiri
-	if claim.UserID != id {
+	// User hanya boleh mengupdate datanya sendiri, kecuali admin
+	if !model.CanAccessUser(claim, id) {
 		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
 	}
 
@@ -156,7 +290,7 @@ func (handler *UserHandler) Update(c echo.Context) error {
 
 	err = handler.userUsecase.Update(c.Request().Context(), id, body)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update user")
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -177,13 +311,13 @@ func (handler *UserHandler) Delete(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
 	}
 
-	if claim.UserID != id {
+	if !model.CanAccessUser(claim, id) {
 		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
 	}
 
 	err = handler.userUsecase.Delete(c.Request().Context(), id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete user")
+		return err
 	}
 
 	return c.JSON(http.StatusOK, Response{
@@ -191,3 +325,100 @@ func (handler *UserHandler) Delete(c echo.Context) error {
 		Message: "User deleted successfully",
 	})
 }
+
+func (handler *UserHandler) ListSessions(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	token, _ := bearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+	sessions, err := handler.userUsecase.ListSessions(c.Request().Context(), claim.UserID, token)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   sessions,
+	})
+}
+
+func (handler *UserHandler) RevokeSession(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid session ID format")
+	}
+
+	err = handler.userUsecase.RevokeSession(c.Request().Context(), claim.UserID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Session revoked",
+	})
+}
+
+func (handler *UserHandler) RevokeOtherSessions(c echo.Context) error {
+	claim, ok := c.Request().Context().Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	token, ok := bearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing token")
+	}
+
+	err := handler.userUsecase.RevokeOtherSessions(c.Request().Context(), claim.UserID, token)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Other sessions revoked",
+	})
+}
+
+func (handler *UserHandler) ListUserSessions(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID format")
+	}
+
+	sessions, err := handler.userUsecase.ListSessions(c.Request().Context(), userID, "")
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status: http.StatusOK,
+		Data:   sessions,
+	})
+}
+
+// RevokeUserSessions lets an admin sign a user out of every device.
+func (handler *UserHandler) RevokeUserSessions(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID format")
+	}
+
+	err = handler.userUsecase.RevokeAllSessions(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "All sessions revoked",
+	})
+}
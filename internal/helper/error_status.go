@@ -0,0 +1,40 @@
+package helper
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+type errorStatus struct {
+	http int
+	grpc codes.Code
+}
+
+// errorStatuses is the single mapping of domain error kinds to transport
+// status codes, shared by the HTTP error handler and the gRPC interceptor.
+var errorStatuses = map[model.ErrorKind]errorStatus{
+	model.KindInvalidArgument:    {http.StatusBadRequest, codes.InvalidArgument},
+	model.KindValidation:         {http.StatusUnprocessableEntity, codes.InvalidArgument},
+	model.KindUnauthenticated:    {http.StatusUnauthorized, codes.Unauthenticated},
+	model.KindForbidden:          {http.StatusForbidden, codes.PermissionDenied},
+	model.KindNotFound:           {http.StatusNotFound, codes.NotFound},
+	model.KindAlreadyExists:      {http.StatusConflict, codes.AlreadyExists},
+	model.KindConflict:           {http.StatusConflict, codes.Aborted},
+	model.KindFailedPrecondition: {http.StatusConflict, codes.FailedPrecondition},
+	model.KindRateLimited:        {http.StatusTooManyRequests, codes.ResourceExhausted},
+	model.KindInternal:           {http.StatusInternalServerError, codes.Internal},
+}
+
+// ErrorStatus resolves err to its domain error and the HTTP and gRPC status
+// codes it maps to.
+func ErrorStatus(err error) (domainErr *model.Error, httpStatus int, grpcCode codes.Code) {
+	domainErr = model.AsError(err)
+	status, ok := errorStatuses[domainErr.Kind]
+	if !ok {
+		status = errorStatuses[model.KindInternal]
+	}
+	return domainErr, status.http, status.grpc
+}
@@ -20,18 +20,35 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-func GenerateToken(userID int64) (strToken string, err error) {
-	expiredAt := time.Now().UTC().Add(config.JWTExp())
-	strToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"exp":     expiredAt.Unix(),
+func GenerateToken(userID int64, role string) (string, error) {
+	keys, err := JWTKeys()
+	if err != nil {
+		return "", err
+	}
+
+	// A random jti keeps two tokens issued for the same user in the same
+	// second apart; session rows are looked up by token.
+	tokenID, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	issuedAt := time.Now().UTC()
+	return keys.Sign(jwt.MapClaims{
+		"jti":     tokenID,
+		"iat":     issuedAt.Unix(),
+		"exp":     issuedAt.Add(config.JWTExp()).Unix(),
 		"user_id": userID,
-	}).SignedString([]byte(config.JWTSigningKey()))
-	return
+		"role":    role,
+	})
 }
 
-func DecodeToken(token string, claim *model.CustomClaims) (err error) {
-	jwt.ParseWithClaims(token, claim, func(t *jwt.Token) (interface{}, error) {
-		return []byte(config.JWTSigningKey()), nil
-	})
-	return
+// DecodeToken verifies token and fills claim. It fails for expired tokens,
+// bad signatures and keys that are no longer configured.
+func DecodeToken(token string, claim *model.CustomClaims) error {
+	keys, err := JWTKeys()
+	if err != nil {
+		return err
+	}
+	return keys.Parse(token, claim)
 }
@@ -0,0 +1,223 @@
+package helper
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/config"
+)
+
+var ErrUnknownKeyID = errors.New("unknown signing key id")
+
+// JWTKeySet holds the keys tokens are signed and verified with, indexed by
+// kid.
+type JWTKeySet struct {
+	active *jwtKey
+	keys   map[string]*jwtKey
+}
+
+type jwtKey struct {
+	id         string
+	method     jwt.SigningMethod
+	privateKey crypto.PrivateKey
+	publicKey  crypto.PublicKey
+}
+
+// JSONWebKey is the public part of a signing key as published in the JWKS
+// document (RFC 7517).
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+var (
+	jwtKeysOnce sync.Once
+	jwtKeys     *JWTKeySet
+	jwtKeysErr  error
+)
+
+// JWTKeys loads the configured keys on first use.
+func JWTKeys() (*JWTKeySet, error) {
+	jwtKeysOnce.Do(func() {
+		jwtKeys, jwtKeysErr = loadJWTKeys(config.JWTActiveKeyID(), config.JWTKeys())
+	})
+	return jwtKeys, jwtKeysErr
+}
+
+func loadJWTKeys(activeID string, configured []config.JWTKey) (*JWTKeySet, error) {
+	if len(configured) == 0 && config.ENV() == "development" {
+		logrus.Warn("No jwt.keys configured, signing with an ephemeral key; tokens will not survive a restart")
+		return ephemeralJWTKeys()
+	}
+
+	set := &JWTKeySet{keys: make(map[string]*jwtKey, len(configured))}
+	for _, c := range configured {
+		if c.ID == "" {
+			return nil, errors.New("jwt key without kid")
+		}
+		if _, exists := set.keys[c.ID]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id %q", c.ID)
+		}
+
+		key, err := loadJWTKey(c)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", c.ID, err)
+		}
+		set.keys[c.ID] = key
+	}
+
+	active, ok := set.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("jwt.active_kid %q is not among jwt.keys", activeID)
+	}
+	if active.privateKey == nil {
+		return nil, fmt.Errorf("active jwt key %q has no private key", activeID)
+	}
+	set.active = active
+
+	return set, nil
+}
+
+func loadJWTKey(c config.JWTKey) (*jwtKey, error) {
+	key := &jwtKey{id: c.ID}
+
+	switch c.Algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		key.method = jwt.SigningMethodRS256
+	case jwt.SigningMethodEdDSA.Alg():
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", c.Algorithm)
+	}
+
+	if c.PrivateKeyFile != "" {
+		pem, err := os.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if key.method == jwt.SigningMethodRS256 {
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.privateKey, key.publicKey = privateKey, privateKey.Public()
+		} else {
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.privateKey, key.publicKey = privateKey, privateKey.(ed25519.PrivateKey).Public()
+		}
+		return key, nil
+	}
+
+	if c.PublicKeyFile == "" {
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+	pem, err := os.ReadFile(c.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if key.method == jwt.SigningMethodRS256 {
+		key.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+	} else {
+		key.publicKey, err = jwt.ParseEdPublicKeyFromPEM(pem)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func ephemeralJWTKeys() (*JWTKeySet, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &jwtKey{
+		id:         "ephemeral",
+		method:     jwt.SigningMethodEdDSA,
+		privateKey: privateKey,
+		publicKey:  publicKey,
+	}
+	return &JWTKeySet{
+		active: key,
+		keys:   map[string]*jwtKey{key.id: key},
+	}, nil
+}
+
+// Sign signs claims with the active key and sets its kid in the header.
+func (s *JWTKeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.method, claims)
+	token.Header["kid"] = s.active.id
+	return token.SignedString(s.active.privateKey)
+}
+
+// Parse verifies token against the key named by its kid header.
+func (s *JWTKeySet) Parse(token string, claims jwt.Claims) error {
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := s.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKeyID
+		}
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("key %q does not sign with %s", kid, t.Method.Alg())
+		}
+		return key.publicKey, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	return err
+}
+
+// JWKS returns the public keys of the set, active and retired.
+func (s *JWTKeySet) JWKS() JSONWebKeySet {
+	jwks := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwk := JSONWebKey{
+			KeyID:     key.id,
+			Use:       "sig",
+			Algorithm: key.method.Alg(),
+		}
+		switch publicKey := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID
+	})
+	return jwks
+}
@@ -0,0 +1,134 @@
+package helper
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of RFC 6238 Appendix B,
+// "12345678901234567890", in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The RFC lists 8-digit codes; 6-digit codes are their last 6 digits.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range rfc6238Vectors {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			if got := totpCode(key, TOTPStep(time.Unix(tt.unix, 0))); got != tt.code {
+				t.Errorf("totpCode() = %s, want %s", got, tt.code)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPRFC6238(t *testing.T) {
+	for _, tt := range rfc6238Vectors {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			now := time.Unix(tt.unix, 0)
+			step, ok := ValidateTOTP(rfc6238Secret, tt.code, now)
+			if !ok {
+				t.Fatalf("ValidateTOTP(%s) rejected a valid code", tt.code)
+			}
+			if want := TOTPStep(now); step != want {
+				t.Errorf("ValidateTOTP() step = %d, want %d", step, want)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1234567890, 0)
+	current := TOTPStep(now)
+
+	tests := []struct {
+		name   string
+		offset int64
+		valid  bool
+	}{
+		{"two steps behind", -2, false},
+		{"one step behind", -1, true},
+		{"current step", 0, true},
+		{"one step ahead", 1, true},
+		{"two steps ahead", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := totpCode(key, current+tt.offset)
+			step, ok := ValidateTOTP(rfc6238Secret, code, now)
+			if ok != tt.valid {
+				t.Fatalf("ValidateTOTP() ok = %v, want %v", ok, tt.valid)
+			}
+			if ok && step != current+tt.offset {
+				t.Errorf("ValidateTOTP() step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+// A code stays valid for the whole skew window, so callers record the step
+// it matched and only accept later steps, as UseTOTPStep does. Replaying
+// the code within the window must map to the same step to be caught.
+func TestValidateTOTPStepReuse(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issued := time.Unix(1234567890, 0)
+	code := totpCode(key, TOTPStep(issued))
+
+	lastUsedStep, ok := ValidateTOTP(rfc6238Secret, code, issued)
+	if !ok {
+		t.Fatal("ValidateTOTP() rejected a fresh code")
+	}
+
+	for _, delay := range []time.Duration{0, 10 * time.Second, totpPeriod * time.Second} {
+		step, ok := ValidateTOTP(rfc6238Secret, code, issued.Add(delay))
+		if !ok {
+			t.Fatalf("ValidateTOTP() after %s rejected the code", delay)
+		}
+		if step > lastUsedStep {
+			t.Errorf("replay after %s matched step %d, newer than used step %d", delay, step, lastUsedStep)
+		}
+	}
+
+	next := totpCode(key, TOTPStep(issued)+1)
+	step, ok := ValidateTOTP(rfc6238Secret, next, issued.Add(totpPeriod*time.Second))
+	if !ok || step <= lastUsedStep {
+		t.Errorf("code of the next step: step = %d, ok = %v; want a step after %d", step, ok, lastUsedStep)
+	}
+}
+
+func TestValidateTOTPMalformed(t *testing.T) {
+	now := time.Unix(59, 0)
+	for _, code := range []string{"", "28708", "2870820", "abcdef"} {
+		if _, ok := ValidateTOTP(rfc6238Secret, code, now); ok {
+			t.Errorf("ValidateTOTP(%q) accepted a malformed code", code)
+		}
+	}
+	if _, ok := ValidateTOTP("not base32!", "287082", now); ok {
+		t.Error("ValidateTOTP() accepted a malformed secret")
+	}
+}
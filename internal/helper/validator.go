@@ -1,5 +1,157 @@
 package helper
 
-import "github.com/go-playground/validator/v10"
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
-var Validator = validator.New()
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+var Validator = newValidator()
+
+var translator *ut.UniversalTranslator
+
+// customTranslations covers the tags the validator ships no message for.
+var customTranslations = map[string]map[string]string{
+	"en": {
+		"iso4217":          "{0} must be a valid ISO 4217 currency code",
+		"money_currency":   "{0} must be a currency with two decimal places",
+		"iso3166_1_alpha2": "{0} must be a valid ISO 3166-1 alpha-2 country code",
+	},
+	"id": {
+		"iso4217":          "{0} harus berupa kode mata uang ISO 4217 yang valid",
+		"money_currency":   "{0} harus berupa mata uang dengan dua angka desimal",
+		"iso3166_1_alpha2": "{0} harus berupa kode negara ISO 3166-1 alpha-2 yang valid",
+		"required_if":      "{0} wajib diisi",
+	},
+}
+
+// newValidator reports fields by their json names, as clients know them,
+// and registers English and Indonesian messages. English is the fallback.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	mustRegister(v.RegisterValidation("money_currency", func(fl validator.FieldLevel) bool {
+		return model.IsMoneyCurrency(fl.Field().String())
+	}))
+
+	english := en.New()
+	translator = ut.New(english, english, id.New())
+
+	enTrans, _ := translator.GetTranslator("en")
+	idTrans, _ := translator.GetTranslator("id")
+	mustRegister(en_translations.RegisterDefaultTranslations(v, enTrans))
+	mustRegister(id_translations.RegisterDefaultTranslations(v, idTrans))
+
+	for _, trans := range []ut.Translator{enTrans, idTrans} {
+		for tag, text := range customTranslations[trans.Locale()] {
+			mustRegister(v.RegisterTranslation(tag, trans, registerMessage(tag, text), translateMessage))
+		}
+	}
+
+	return v
+}
+
+func mustRegister(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func registerMessage(tag string, text string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, text, true)
+	}
+}
+
+func translateMessage(trans ut.Translator, fe validator.FieldError) string {
+	message, err := trans.T(fe.Tag(), fe.Field())
+	if err != nil {
+		return fe.Error()
+	}
+	return message
+}
+
+// ValidationTranslator picks the translator for the most preferred
+// language of an Accept-Language header that has one, English otherwise.
+func ValidationTranslator(acceptLanguage string) ut.Translator {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		languages = append(languages, language{tag: tag, quality: quality})
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	locales := make([]string, 0, len(languages)*2)
+	for _, l := range languages {
+		tag := strings.ReplaceAll(l.tag, "-", "_")
+		base, _, _ := strings.Cut(tag, "_")
+		locales = append(locales, tag, base)
+	}
+
+	trans, _ := translator.FindTranslator(locales...)
+	return trans
+}
+
+// FieldViolations lists the failed rules of a validation error, with
+// messages in the language of trans. It returns nil for other errors.
+func FieldViolations(err error, trans ut.Translator) []model.FieldViolation {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	violations := make([]model.FieldViolation, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		violations = append(violations, model.FieldViolation{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return violations
+}
+
+// fieldPath is the namespace of the field without the struct name, e.g.
+// "items[0].quantity".
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+type LogMailer struct {
+	dir  string
+	from string
+}
+
+// NewLogMailer is for local development: messages are written to dir as
+// .eml files, or logged when dir is empty. Nothing is delivered.
+func NewLogMailer(dir, from string) model.Mailer {
+	return &LogMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *LogMailer) Send(ctx context.Context, email model.Email) error {
+	if m.dir == "" {
+		logrus.WithFields(logrus.Fields{
+			"to":      email.To,
+			"subject": email.Subject,
+		}).Info("Mail not sent (log mailer):\n", email.Body)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d.eml", time.Now().UnixNano())
+	return os.WriteFile(filepath.Join(m.dir, name), buildMessage(m.from, email), 0o600)
+}
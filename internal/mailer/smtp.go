@@ -0,0 +1,141 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+// smtpTimeout bounds a whole delivery when ctx sets no earlier deadline.
+const smtpTimeout = time.Minute
+
+type SMTPMailer struct {
+	host string
+	addr string
+	auth smtp.Auth
+	from string
+	// sender is the bare address of from, used for the SMTP envelope.
+	sender string
+}
+
+// NewSMTPMailer sends mail through an SMTP relay. Authentication is skipped
+// when username is empty.
+func NewSMTPMailer(host string, port int, username, password, from string) model.Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	sender := from
+	if addr, err := mail.ParseAddress(from); err == nil {
+		sender = addr.Address
+	}
+
+	return &SMTPMailer{
+		host:   host,
+		addr:   net.JoinHostPort(host, strconv.Itoa(port)),
+		auth:   auth,
+		from:   from,
+		sender: sender,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, email model.Email) error {
+	if err := m.send(ctx, email); err != nil {
+		return fmt.Errorf("send mail to %s: %w", email.To, err)
+	}
+	return nil
+}
+
+// send does what smtp.SendMail does, but on a connection that is dialed
+// with ctx, has a deadline and is closed when ctx is done, so a stalled
+// relay cannot hold the caller forever.
+func (m *SMTPMailer) send(ctx context.Context, email model.Email) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	defer func() {
+		// Report why the connection was cut rather than how it failed.
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+	}()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(m.auth); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := client.Mail(m.sender); err != nil {
+		return err
+	}
+	if err := client.Rcpt(email.To); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMessage(m.from, email)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage renders a plain text RFC 5322 message. Header values have
+// line breaks removed so they cannot inject extra headers.
+func buildMessage(from string, email model.Email) []byte {
+	headerValue := strings.NewReplacer("\r", "", "\n", "")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue.Replace(email.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue.Replace(email.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(email.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+var ErrAddressNotFound = NewError(KindNotFound, "address_not_found", "address not found")
+
+type IAddressRepository interface {
+	FindAllByUserID(ctx context.Context, userID int64) ([]*UserAddress, error)
+	FindById(ctx context.Context, userID int64, id int64) (*UserAddress, error)
+	FindDefault(ctx context.Context, userID int64) (*UserAddress, error)
+	Create(ctx context.Context, address *UserAddress) error
+	Update(ctx context.Context, address UserAddress) error
+	Delete(ctx context.Context, userID int64, id int64) error
+}
+
+type IAddressUsecase interface {
+	FindAllByUserID(ctx context.Context, userID int64) ([]*UserAddress, error)
+	FindById(ctx context.Context, userID int64, id int64) (*UserAddress, error)
+	Create(ctx context.Context, userID int64, in CreateAddressInput) (*UserAddress, error)
+	Update(ctx context.Context, userID int64, id int64, in UpdateAddressInput) (*UserAddress, error)
+	Delete(ctx context.Context, userID int64, id int64) error
+}
+
+// UserAddress is an address in a user's address book. At most one address
+// per user is the default.
+type UserAddress struct {
+	ID            int64      `json:"id"`
+	UserID        int64      `json:"user_id"`
+	Label         string     `json:"label"`
+	RecipientName string     `json:"recipient_name"`
+	Phone         string     `json:"phone"`
+	Line1         string     `json:"line1"`
+	Line2         string     `json:"line2,omitempty"`
+	City          string     `json:"city"`
+	Province      string     `json:"province"`
+	PostalCode    string     `json:"postal_code"`
+	CountryCode   string     `json:"country_code"`
+	IsDefault     bool       `json:"is_default"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	DeletedAt     *time.Time `json:"-"`
+}
+
+// Snapshot copies the address as it is now, for storing on an order.
+func (a *UserAddress) Snapshot() *OrderAddress {
+	return &OrderAddress{
+		AddressID:     a.ID,
+		Label:         a.Label,
+		RecipientName: a.RecipientName,
+		Phone:         a.Phone,
+		Line1:         a.Line1,
+		Line2:         a.Line2,
+		City:          a.City,
+		Province:      a.Province,
+		PostalCode:    a.PostalCode,
+		CountryCode:   a.CountryCode,
+	}
+}
+
+// OrderAddress is the copy of a user address kept on an order, so later
+// edits to the address book do not change past orders.
+type OrderAddress struct {
+	AddressID     int64  `json:"address_id"`
+	Label         string `json:"label"`
+	RecipientName string `json:"recipient_name"`
+	Phone         string `json:"phone"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2,omitempty"`
+	City          string `json:"city"`
+	Province      string `json:"province"`
+	PostalCode    string `json:"postal_code"`
+	CountryCode   string `json:"country_code"`
+}
+
+type CreateAddressInput struct {
+	Label         string `json:"label" validate:"max=50"`
+	RecipientName string `json:"recipient_name" validate:"required,max=255"`
+	Phone         string `json:"phone" validate:"required,max=30"`
+	Line1         string `json:"line1" validate:"required,max=255"`
+	Line2         string `json:"line2" validate:"max=255"`
+	City          string `json:"city" validate:"required,max=100"`
+	Province      string `json:"province" validate:"required,max=100"`
+	PostalCode    string `json:"postal_code" validate:"required,max=20"`
+	CountryCode   string `json:"country_code" validate:"required,iso3166_1_alpha2"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+type UpdateAddressInput struct {
+	Label         string `json:"label" validate:"max=50"`
+	RecipientName string `json:"recipient_name" validate:"required,max=255"`
+	Phone         string `json:"phone" validate:"required,max=30"`
+	Line1         string `json:"line1" validate:"required,max=255"`
+	Line2         string `json:"line2" validate:"max=255"`
+	City          string `json:"city" validate:"required,max=100"`
+	Province      string `json:"province" validate:"required,max=100"`
+	PostalCode    string `json:"postal_code" validate:"required,max=20"`
+	CountryCode   string `json:"country_code" validate:"required,iso3166_1_alpha2"`
+	IsDefault     bool   `json:"is_default"`
+}
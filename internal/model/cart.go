@@ -0,0 +1,78 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	ErrCartEmpty        = NewError(KindInvalidArgument, "cart_empty", "cart is empty")
+	ErrCartItemNotFound = NewError(KindNotFound, "cart_item_not_found", "cart item not found")
+)
+
+type ICartRepository interface {
+	FindOrCreate(ctx context.Context, userID int64) (*Cart, error)
+	AddItem(ctx context.Context, item CartItem) error
+	UpdateItem(ctx context.Context, item CartItem) error
+	DeleteItem(ctx context.Context, cartID int64, productID int64) error
+	// RemoveCheckedOut removes the items of cart, a snapshot that was
+	// checked out, from the stored cart. Items added or changed since the
+	// snapshot was read are kept.
+	RemoveCheckedOut(ctx context.Context, cart Cart) error
+}
+
+type ICartUsecase interface {
+	FindByUserID(ctx context.Context, userID int64) (*Cart, error)
+	AddItem(ctx context.Context, userID int64, in AddCartItemInput) (*Cart, error)
+	UpdateItem(ctx context.Context, userID int64, productID int64, in UpdateCartItemInput) (*Cart, error)
+	RemoveItem(ctx context.Context, userID int64, productID int64) (*Cart, error)
+	Checkout(ctx context.Context, userID int64, in CheckoutCartInput) (*Order, error)
+}
+
+type Cart struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	CartItems []CartItem `json:"items"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Computed from current product prices when the cart is read.
+	Subtotal Money  `json:"subtotal" gorm:"-"`
+	Currency string `json:"currency,omitempty" gorm:"-"`
+}
+
+type CartItem struct {
+	ID        int64     `json:"id"`
+	CartID    int64     `json:"cart_id"`
+	ProductID int64     `json:"product_id"`
+	Quantity  int64     `json:"quantity"`
+	Price     Money     `json:"price"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Revalidated against the product every time the cart is read. Price
+	// holds the price seen when the item was last added or updated.
+	ProductName    string `json:"product_name,omitempty" gorm:"-"`
+	CurrentPrice   Money  `json:"current_price" gorm:"-"`
+	PriceChanged   bool   `json:"price_changed" gorm:"-"`
+	AvailableStock int64  `json:"available_stock" gorm:"-"`
+	Available      bool   `json:"available" gorm:"-"`
+	Message        string `json:"message,omitempty" gorm:"-"`
+}
+
+type AddCartItemInput struct {
+	ProductID int64 `json:"product_id" validate:"required"`
+	Quantity  int64 `json:"quantity" validate:"required,gt=0"`
+}
+
+type UpdateCartItemInput struct {
+	Quantity int64 `json:"quantity" validate:"required,gt=0"`
+}
+
+type CheckoutCartInput struct {
+	CouponCode        string `json:"coupon_code" validate:"max=50"`
+	ShippingAddressID int64  `json:"shipping_address_id"`
+	BillingAddressID  int64  `json:"billing_address_id"`
+
+	IdempotencyKey string `json:"-" validate:"max=255"`
+}
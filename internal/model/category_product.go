@@ -5,6 +5,8 @@ import (
 	"time"
 )
 
+var ErrCategoryNotFound = NewError(KindNotFound, "category_not_found", "category not found")
+
 type ICategoryRepository interface {
 	FindAll(ctx context.Context, category Category) ([]*Category, error)
 	FindById(ctx context.Context, id int64) (*Category, error)
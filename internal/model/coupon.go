@@ -0,0 +1,119 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	CouponTypePercentage = "percentage"
+	CouponTypeFixed      = "fixed"
+)
+
+var (
+	ErrCouponNotFound       = NewError(KindNotFound, "coupon_not_found", "coupon not found")
+	ErrCouponNotApplicable  = NewError(KindValidation, "coupon_not_applicable", "coupon is not applicable")
+	ErrCouponUsageExhausted = NewError(KindFailedPrecondition, "coupon_usage_exhausted", "coupon usage limit has been reached")
+)
+
+// CouponNotApplicableError explains why a coupon cannot be applied to an
+// order. It matches ErrCouponNotApplicable with errors.Is.
+type CouponNotApplicableError struct {
+	Code   string
+	Reason string
+}
+
+func (e *CouponNotApplicableError) Error() string {
+	return fmt.Sprintf("coupon %s is not applicable: %s", e.Code, e.Reason)
+}
+
+func (e *CouponNotApplicableError) Unwrap() error {
+	return ErrCouponNotApplicable
+}
+
+type ICouponRepository interface {
+	FindAll(ctx context.Context) ([]*Coupon, error)
+	FindById(ctx context.Context, id int64) (*Coupon, error)
+	FindByCode(ctx context.Context, code string) (*Coupon, error)
+	Create(ctx context.Context, coupon *Coupon) error
+	Update(ctx context.Context, coupon Coupon) error
+	Delete(ctx context.Context, id int64) error
+	CountRedemptionsByUser(ctx context.Context, couponID int64, userID int64) (int64, error)
+}
+
+type ICouponUsecase interface {
+	FindAll(ctx context.Context) ([]*Coupon, error)
+	FindById(ctx context.Context, id int64) (*Coupon, error)
+	Create(ctx context.Context, in CreateCouponInput) (*Coupon, error)
+	Update(ctx context.Context, id int64, in UpdateCouponInput) (*Coupon, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// Coupon is a promotion code. Percentage coupons take PercentOff percent of
+// the eligible items, optionally capped by MaxDiscount; fixed coupons take
+// AmountOff. A coupon with ProductIDs or CategoryIDs only discounts matching
+// items. Nil usage limits mean unlimited.
+type Coupon struct {
+	ID             int64         `json:"id"`
+	Code           string        `json:"code"`
+	Type           string        `json:"type"`
+	PercentOff     int64         `json:"percent_off,omitempty"`
+	AmountOff      Money         `json:"amount_off,omitempty"`
+	Currency       string        `json:"currency,omitempty"`
+	MaxDiscount    Money         `json:"max_discount,omitempty"`
+	MinOrderAmount Money         `json:"min_order_amount"`
+	UsageLimit     *int64        `json:"usage_limit"`
+	PerUserLimit   *int64        `json:"per_user_limit"`
+	UsedCount      int64         `json:"used_count"`
+	StartsAt       *time.Time    `json:"starts_at"`
+	EndsAt         *time.Time    `json:"ends_at"`
+	ProductIDs     pq.Int64Array `json:"product_ids" gorm:"type:integer[]"`
+	CategoryIDs    pq.Int64Array `json:"category_ids" gorm:"type:integer[]"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	DeletedAt      *time.Time    `json:"-"`
+}
+
+type CouponRedemption struct {
+	ID             int64     `json:"id"`
+	CouponID       int64     `json:"coupon_id"`
+	UserID         int64     `json:"user_id"`
+	OrderID        string    `json:"order_id"`
+	DiscountAmount Money     `json:"discount_amount"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type CreateCouponInput struct {
+	Code           string     `json:"code" validate:"required,max=50"`
+	Type           string     `json:"type" validate:"required,oneof=percentage fixed"`
+	PercentOff     int64      `json:"percent_off" validate:"required_if=Type percentage,min=0,max=100"`
+	AmountOff      Money      `json:"amount_off" validate:"required_if=Type fixed,min=0"`
+	Currency       string     `json:"currency" validate:"omitempty,iso4217,money_currency"`
+	MaxDiscount    Money      `json:"max_discount" validate:"min=0"`
+	MinOrderAmount Money      `json:"min_order_amount" validate:"min=0"`
+	UsageLimit     *int64     `json:"usage_limit" validate:"omitempty,min=1"`
+	PerUserLimit   *int64     `json:"per_user_limit" validate:"omitempty,min=1"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	ProductIDs     []int64    `json:"product_ids"`
+	CategoryIDs    []int64    `json:"category_ids"`
+}
+
+type UpdateCouponInput struct {
+	Code           string     `json:"code" validate:"required,max=50"`
+	Type           string     `json:"type" validate:"required,oneof=percentage fixed"`
+	PercentOff     int64      `json:"percent_off" validate:"required_if=Type percentage,min=0,max=100"`
+	AmountOff      Money      `json:"amount_off" validate:"required_if=Type fixed,min=0"`
+	Currency       string     `json:"currency" validate:"omitempty,iso4217,money_currency"`
+	MaxDiscount    Money      `json:"max_discount" validate:"min=0"`
+	MinOrderAmount Money      `json:"min_order_amount" validate:"min=0"`
+	UsageLimit     *int64     `json:"usage_limit" validate:"omitempty,min=1"`
+	PerUserLimit   *int64     `json:"per_user_limit" validate:"omitempty,min=1"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	ProductIDs     []int64    `json:"product_ids"`
+	CategoryIDs    []int64    `json:"category_ids"`
+}
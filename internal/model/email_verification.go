@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+var (
+	ErrEmailVerificationTokenInvalid = NewError(KindInvalidArgument, "email_verification_token_invalid", "email verification token is invalid or expired")
+	ErrEmailAlreadyVerified          = NewError(KindFailedPrecondition, "email_already_verified", "email is already verified")
+	ErrVerificationResendTooSoon     = NewError(KindRateLimited, "verification_resend_too_soon", "verification email was sent recently, try again later")
+	ErrEmailNotVerified              = NewError(KindForbidden, "email_not_verified", "email address must be verified first")
+)
+
+// EmailVerificationToken proves ownership of the email address of a user.
+// Only the hash of the token is stored.
+type EmailVerificationToken struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrorKind classifies domain errors. The delivery layers derive HTTP and
+// gRPC status codes from it.
+type ErrorKind string
+
+const (
+	KindInvalidArgument    ErrorKind = "invalid_argument"
+	KindValidation         ErrorKind = "validation"
+	KindUnauthenticated    ErrorKind = "unauthenticated"
+	KindForbidden          ErrorKind = "forbidden"
+	KindNotFound           ErrorKind = "not_found"
+	KindAlreadyExists      ErrorKind = "already_exists"
+	KindConflict           ErrorKind = "conflict"
+	KindFailedPrecondition ErrorKind = "failed_precondition"
+	KindRateLimited        ErrorKind = "rate_limited"
+	KindInternal           ErrorKind = "internal"
+)
+
+// Error is a domain error. Code is a stable, machine-readable identifier
+// clients can switch on; Message is meant for people and may change.
+//
+// The sentinel errors of this package are *Error values, so they can be
+// matched with errors.Is and wrapped with fmt.Errorf("...: %w", err).
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+}
+
+func NewError(kind ErrorKind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// FieldViolation describes a field of a request that failed validation.
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+var (
+	ErrInternal   = NewError(KindInternal, "internal", "internal server error")
+	ErrValidation = NewError(KindValidation, "validation_failed", "request validation failed")
+)
+
+// AsError resolves err to the domain error it wraps, keeping the message of
+// err itself so wrapped and structured errors read as before. Validation
+// errors map to ErrValidation, their fields are reported separately, and
+// anything unknown to ErrInternal, so the raw message of an unexpected
+// error is never exposed.
+func AsError(err error) *Error {
+	var domainErr *Error
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &domainErr):
+		return &Error{Kind: domainErr.Kind, Code: domainErr.Code, Message: err.Error()}
+	case errors.As(err, &validationErrs):
+		return ErrValidation
+	}
+	return ErrInternal
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyReused     = NewError(KindValidation, "idempotency_key_reused", "idempotency key has already been used with a different request")
+	ErrIdempotencyKeyInProgress = NewError(KindConflict, "idempotency_key_in_progress", "a request with this idempotency key is still being processed")
+)
+
+type IIdempotencyKeyRepository interface {
+	// Reserve claims record.Key for record.UserID. If an unexpired record
+	// already holds the key it is returned instead and created is false.
+	Reserve(ctx context.Context, record IdempotencyKey) (stored *IdempotencyKey, created bool, err error)
+	Complete(ctx context.Context, userID int64, key string, orderID string) error
+	Release(ctx context.Context, userID int64, key string) error
+}
+
+type IdempotencyKey struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"`
+	OrderID     *string   `json:"order_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginThrottlePolicyRetryAt(t *testing.T) {
+	policy := LoginThrottlePolicy{
+		FreeAttempts: 3,
+		BackoffBase:  time.Minute,
+		BackoffMax:   10 * time.Minute,
+	}
+	last := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	lockedUntil := last.Add(30 * time.Minute)
+	earlierLock := last.Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		failures    int64
+		lockedUntil *time.Time
+		want        time.Duration
+	}{
+		{name: "no failures", failures: 0, want: 0},
+		{name: "last free attempt", failures: 3, want: 0},
+		{name: "first backoff", failures: 4, want: time.Minute},
+		{name: "doubles", failures: 5, want: 2 * time.Minute},
+		{name: "doubles again", failures: 7, want: 8 * time.Minute},
+		{name: "capped", failures: 8, want: 10 * time.Minute},
+		{name: "capped when the shift overflows", failures: 100, want: 10 * time.Minute},
+		{name: "lock outlasts backoff", failures: 4, lockedUntil: &lockedUntil, want: 30 * time.Minute},
+		{name: "expired lock", failures: 4, lockedUntil: &earlierLock, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.RetryAt(LoginAttempt{
+				Failures:      tt.failures,
+				LastFailureAt: last,
+				LockedUntil:   tt.lockedUntil,
+			})
+			if want := last.Add(tt.want); !got.Equal(want) {
+				t.Errorf("RetryAt() = last failure + %s, want + %s", got.Sub(last), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginThrottlePolicyLockoutThreshold(t *testing.T) {
+	policy := LoginThrottlePolicy{AccountLockoutThreshold: 10, IPLockoutThreshold: 50}
+
+	if got := policy.LockoutThreshold(LoginAttemptScopeAccount); got != 10 {
+		t.Errorf("LockoutThreshold(account) = %d, want 10", got)
+	}
+	if got := policy.LockoutThreshold(LoginAttemptScopeIP); got != 50 {
+		t.Errorf("LockoutThreshold(ip) = %d, want 50", got)
+	}
+}
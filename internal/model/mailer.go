@@ -0,0 +1,14 @@
+package model
+
+import "context"
+
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional email such as password reset links.
+type Mailer interface {
+	Send(ctx context.Context, email Email) error
+}
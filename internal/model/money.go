@@ -0,0 +1,159 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MoneyScale is the number of minor-unit digits kept for every amount. Only
+// currencies with that many minor-unit digits can be used, see
+// IsMoneyCurrency.
+const MoneyScale = 2
+
+const moneyUnit = 100
+
+var ErrCurrencyMismatch = NewError(KindFailedPrecondition, "currency_mismatch", "all items in an order must use the same currency")
+
+// otherScaleCurrencies are the ISO 4217 codes whose minor unit is not
+// 1/100: no decimals (JPY, KRW, ...), three (BHD, KWD, ...), four (CLF,
+// UYW), or none defined at all, as for precious metals and funds.
+var otherScaleCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true,
+	"JPY": true, "KMF": true, "KRW": true, "PYG": true, "RWF": true,
+	"UGX": true, "UYI": true, "VND": true, "VUV": true, "XAF": true,
+	"XOF": true, "XPF": true,
+
+	"BHD": true, "IQD": true, "JOD": true, "KWD": true, "LYD": true,
+	"OMR": true, "TND": true,
+
+	"CLF": true, "UYW": true,
+
+	"XAG": true, "XAU": true, "XBA": true, "XBB": true, "XBC": true,
+	"XBD": true, "XDR": true, "XPD": true, "XPT": true, "XSU": true,
+	"XTS": true, "XUA": true, "XXX": true,
+}
+
+// IsMoneyCurrency reports whether Money can hold amounts of the ISO 4217
+// currency code exactly, i.e. whether its minor unit is 1/100.
+func IsMoneyCurrency(code string) bool {
+	return !otherScaleCurrencies[strings.ToUpper(code)]
+}
+
+// Money is an exact amount in minor currency units (1/100 of the major unit).
+// It is stored in NUMERIC columns and encoded in JSON as a decimal number, so
+// amounts never pass through float64.
+type Money int64
+
+// ParseMoney parses a decimal string such as "15000", "12.5" or "-3.25".
+func ParseMoney(s string) (Money, error) {
+	str := strings.TrimSpace(s)
+
+	negative := false
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		negative = str[0] == '-'
+		str = str[1:]
+	}
+
+	whole, frac, _ := strings.Cut(str, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid money amount %q", s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if len(frac) > MoneyScale {
+		if strings.TrimRight(frac[MoneyScale:], "0") != "" {
+			return 0, fmt.Errorf("money amount %q has more than %d decimal places", s, MoneyScale)
+		}
+		frac = frac[:MoneyScale]
+	}
+	frac += strings.Repeat("0", MoneyScale-len(frac))
+
+	for _, r := range whole + frac {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid money amount %q", s)
+		}
+	}
+
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid money amount %q: %w", s, err)
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return Money(amount), nil
+}
+
+func (m Money) String() string {
+	sign := ""
+	amount := int64(m)
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/moneyUnit, MoneyScale, amount%moneyUnit)
+}
+
+// Mul returns the amount multiplied by a quantity.
+func (m Money) Mul(quantity int64) Money {
+	return m * Money(quantity)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts both JSON numbers and quoted decimal strings.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	str := strings.Trim(string(data), `"`)
+	if str == "null" || str == "" {
+		return nil
+	}
+
+	amount, err := ParseMoney(str)
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		if v > math.MaxInt64/moneyUnit || v < math.MinInt64/moneyUnit {
+			return fmt.Errorf("money amount %d out of range", v)
+		}
+		*m = Money(v * moneyUnit)
+		return nil
+	case float64:
+		return m.scanString(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+}
+
+func (m *Money) scanString(s string) error {
+	amount, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
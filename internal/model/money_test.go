@@ -0,0 +1,208 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "15000", want: 1500000},
+		{in: "12.5", want: 1250},
+		{in: "12.50", want: 1250},
+		{in: "0.01", want: 1},
+		{in: ".5", want: 50},
+		{in: "7.", want: 700},
+		{in: " 3.25 ", want: 325},
+		{in: "+3.25", want: 325},
+		{in: "-3.25", want: -325},
+		{in: "-0.01", want: -1},
+		{in: "-0", want: 0},
+
+		// Trailing zeros past the scale do not change the amount.
+		{in: "1.2500", want: 125},
+		{in: "1.005", wantErr: true},
+		{in: "1.001", wantErr: true},
+		{in: "-0.001", wantErr: true},
+
+		{in: "92233720368547758.07", want: math.MaxInt64},
+		{in: "92233720368547758.08", wantErr: true},
+		{in: "-92233720368547758.07", want: -math.MaxInt64},
+		{in: "100000000000000000000", wantErr: true},
+
+		{in: "", wantErr: true},
+		{in: " ", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "--1", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "1,50", wantErr: true},
+		{in: "1e3", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "0x10", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMoney(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMoney(%q) = %d, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMoneyOverflowWrapsParseError(t *testing.T) {
+	_, err := ParseMoney("92233720368547758.08")
+	if !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("ParseMoney() error = %v, want %v", err, strconv.ErrRange)
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{0, "0.00"},
+		{1, "0.01"},
+		{1250, "12.50"},
+		{-1, "-0.01"},
+		{-325, "-3.25"},
+		{math.MaxInt64, "92233720368547758.07"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.in.String(); got != tt.want {
+				t.Errorf("Money(%d).String() = %s, want %s", int64(tt.in), got, tt.want)
+			}
+			back, err := ParseMoney(tt.want)
+			if err != nil || back != tt.in {
+				t.Errorf("ParseMoney(%q) = %d, %v; want %d", tt.want, back, err, int64(tt.in))
+			}
+		})
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    Money
+		wantErr bool
+	}{
+		{name: "nil", src: nil, want: 0},
+		{name: "bytes", src: []byte("15000.00"), want: 1500000},
+		{name: "string", src: "12.5", want: 1250},
+		{name: "negative string", src: "-3.25", want: -325},
+		{name: "int64", src: int64(150), want: 15000},
+		{name: "negative int64", src: int64(-2), want: -200},
+		{name: "float64", src: 12.5, want: 1250},
+		{name: "too many decimals", src: "1.001", wantErr: true},
+		{name: "float64 with too many decimals", src: 0.125, wantErr: true},
+		{name: "int64 overflow", src: int64(math.MaxInt64/moneyUnit + 1), wantErr: true},
+		{name: "int64 underflow", src: int64(math.MinInt64/moneyUnit - 1), wantErr: true},
+		{name: "float64 overflow", src: 1e20, wantErr: true},
+		{name: "garbage", src: []byte("abc"), wantErr: true},
+		{name: "unsupported type", src: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Money(42)
+			err := got.Scan(tt.src)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Scan(%v) = %d, want an error", tt.src, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan(%v): %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan(%v) = %d, want %d", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: `12.5`, want: 1250},
+		{in: `"12.50"`, want: 1250},
+		{in: `-3`, want: -300},
+		{in: `null`, want: 42},
+		{in: `""`, want: 42},
+		{in: `1.005`, wantErr: true},
+		{in: `"abc"`, wantErr: true},
+		{in: `1e3`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := Money(42)
+			err := got.UnmarshalJSON([]byte(tt.in))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("UnmarshalJSON(%s) = %d, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalJSON(%s): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("UnmarshalJSON(%s) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsMoneyCurrency(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"IDR", true},
+		{"USD", true},
+		{"EUR", true},
+		{"idr", true},
+		{"JPY", false},
+		{"jpy", false},
+		{"KRW", false},
+		{"KWD", false},
+		{"BHD", false},
+		{"CLF", false},
+		{"XAU", false},
+		{"XXX", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := IsMoneyCurrency(tt.code); got != tt.want {
+				t.Errorf("IsMoneyCurrency(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,15 +2,104 @@ package model
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
+const (
+	OrderStatusPending    = "pending"
+	OrderStatusPaid       = "paid"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipped    = "shipped"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCancelled  = "cancelled"
+	OrderStatusRefunded   = "refunded"
+	OrderStatusFailed     = "failed"
+)
+
+var (
+	ErrOrderNotFound                = NewError(KindNotFound, "order_not_found", "order not found")
+	ErrInvalidOrderID               = NewError(KindInvalidArgument, "invalid_order_id", "invalid order ID")
+	ErrOrderItemsEmpty              = NewError(KindInvalidArgument, "order_items_empty", "order items cannot be empty")
+	ErrInvalidOrderStatus           = NewError(KindInvalidArgument, "invalid_order_status", "invalid order status")
+	ErrInvalidOrderStatusTransition = NewError(KindFailedPrecondition, "invalid_order_status_transition", "invalid order status transition")
+)
+
+// orderStatusTransitions lists, for every status, the statuses an order may
+// move to next. Statuses without an entry are terminal. Only unpaid orders
+// can be cancelled; once paid, the way out is a refund.
+var orderStatusTransitions = map[string][]string{
+	OrderStatusPending:    {OrderStatusPaid, OrderStatusCancelled, OrderStatusFailed},
+	OrderStatusPaid:       {OrderStatusProcessing, OrderStatusRefunded},
+	OrderStatusProcessing: {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:    {OrderStatusDelivered},
+	OrderStatusDelivered:  {OrderStatusRefunded},
+	OrderStatusCancelled:  {},
+	OrderStatusRefunded:   {},
+	OrderStatusFailed:     {},
+}
+
+// InvalidOrderStatusTransitionError is returned when an order is asked to move
+// to a status that is not reachable from its current one. It matches
+// ErrInvalidOrderStatusTransition with errors.Is.
+type InvalidOrderStatusTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidOrderStatusTransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %q to %q", e.From, e.To)
+}
+
+func (e *InvalidOrderStatusTransitionError) Unwrap() error {
+	return ErrInvalidOrderStatusTransition
+}
+
+func IsValidOrderStatus(status string) bool {
+	_, ok := orderStatusTransitions[status]
+	return ok
+}
+
+func CanTransitionOrderStatus(from, to string) bool {
+	for _, next := range orderStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderStatusReleasesStock reports whether moving an order from one status to
+// another should return its reserved stock to the products. Stock is only
+// released while the goods have not left the warehouse.
+func OrderStatusReleasesStock(from, to string) bool {
+	switch to {
+	case OrderStatusCancelled, OrderStatusFailed, OrderStatusRefunded:
+	default:
+		return false
+	}
+
+	switch from {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusProcessing:
+		return true
+	default:
+		return false
+	}
+}
+
 type IOrderRepository interface {
 	FindAll(ctx context.Context, userID int64) ([]*Order, error)
 	FindById(ctx context.Context, id string) (*Order, error)
 	SaveOrder(ctx context.Context, order *Order) error
 	Update(ctx context.Context, order *Order) error
 	Delete(ctx context.Context, id string) error
+	FindStatusHistory(ctx context.Context, orderID string) ([]*OrderStatusHistory, error)
+}
+
+// OrderIDGenerator hands out identifiers for new orders. Implementations must
+// never return the same ID twice, even when called concurrently.
+type OrderIDGenerator interface {
+	NextID(ctx context.Context) (string, error)
 }
 
 type IOrderUsecase interface {
@@ -20,37 +109,90 @@ type IOrderUsecase interface {
 	Create(ctx context.Context, in CreateOrderInput) (*Order, error)
 	Update(ctx context.Context, order *Order) error
 	Delete(ctx context.Context, id string) error
-	UpdateOrderStatus(ctx context.Context, orderID string, status string) error
+	UpdateOrderStatus(ctx context.Context, orderID string, status string, reason string) error
+	Cancel(ctx context.Context, orderID string, reason string) error
+	Refund(ctx context.Context, orderID string, reason string) error
+	FindStatusHistory(ctx context.Context, orderID string) ([]*OrderStatusHistory, error)
 }
 
+// Order amounts are in Currency. TotalAmount is the grand total:
+// Subtotal - DiscountAmount + TaxAmount + ShippingAmount.
 type Order struct {
-	ID          string      `json:"id"`
-	UserID      int64       `json:"user_id"`
-	TotalAmount float64     `json:"total_amount"`
-	Status      string      `json:"status"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
-	DeletedAt   *time.Time  `json:"-"`
-	OrderItems  []OrderItem `json:"order_items"`
+	ID              string        `json:"id"`
+	UserID          int64         `json:"user_id"`
+	Subtotal        Money         `json:"subtotal"`
+	DiscountAmount  Money         `json:"discount_amount"`
+	TaxAmount       Money         `json:"tax_amount"`
+	ShippingAmount  Money         `json:"shipping_amount"`
+	TotalAmount     Money         `json:"total_amount"`
+	Currency        string        `json:"currency"`
+	CouponID        *int64        `json:"coupon_id,omitempty"`
+	CouponCode      *string       `json:"coupon_code,omitempty"`
+	ShippingAddress *OrderAddress `json:"shipping_address,omitempty" gorm:"type:jsonb;serializer:json"`
+	BillingAddress  *OrderAddress `json:"billing_address,omitempty" gorm:"type:jsonb;serializer:json"`
+	Status          string        `json:"status"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	DeletedAt       *time.Time    `json:"-"`
+	OrderItems      []OrderItem   `json:"order_items"`
+
+	// StatusReason is recorded in the status history when Status changes.
+	StatusReason string `json:"-" gorm:"-"`
+}
+
+type OrderStatusHistory struct {
+	ID           int64     `json:"id"`
+	OrderID      string    `json:"order_id"`
+	FromStatus   *string   `json:"from_status"`
+	ToStatus     string    `json:"to_status"`
+	ActorUserID  *int64    `json:"actor_user_id,omitempty"`
+	ActorService string    `json:"actor_service,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
 }
 
 type OrderItem struct {
-	ID        int64      `json:"id" gorm:"primaryKey;autoIncrement"`
-	OrderID   string     `json:"order_id" gorm:"index"`
-	ProductID int64      `json:"product_id"`
-	Quantity  int64      `json:"quantity"`
-	Price     float64    `json:"price"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	DeletedAt *time.Time `json:"-"`
+	ID             int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID        string     `json:"order_id" gorm:"index"`
+	ProductID      int64      `json:"product_id"`
+	Quantity       int64      `json:"quantity"`
+	Price          Money      `json:"price"`
+	DiscountAmount Money      `json:"discount_amount"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeletedAt      *time.Time `json:"-"`
+
+	// CategoryID is filled from the product when the order is priced.
+	CategoryID int64 `json:"-" gorm:"-"`
 }
 
 type CreateOrderInput struct {
 	UserID     int64             `json:"user_id" validate:"required"`
-	OrderItems []CreateOrderItem `json:"order_items" validate:"required"`
+	OrderItems []CreateOrderItem `json:"order_items" validate:"required,dive"`
+	CouponCode string            `json:"coupon_code" validate:"max=50"`
+
+	// ShippingAddressID defaults to the user's default address.
+	// BillingAddressID defaults to the shipping address.
+	ShippingAddressID int64 `json:"shipping_address_id"`
+	BillingAddressID  int64 `json:"billing_address_id"`
+
+	// IdempotencyKey comes from the Idempotency-Key header, not the body.
+	IdempotencyKey string `json:"-" validate:"max=255"`
 }
 
 type CreateOrderItem struct {
 	ProductID int64 `json:"product_id" validate:"required"`
-	Quantity  int64 `json:"quantity" validate:"required"`
+	Quantity  int64 `json:"quantity" validate:"required,gt=0"`
+}
+
+type CancelOrderInput struct {
+	Reason string `json:"reason"`
+}
+
+type RefundOrderInput struct {
+	Reason string `json:"reason"`
 }
@@ -0,0 +1,71 @@
+package model
+
+import "testing"
+
+var orderStatuses = []string{
+	OrderStatusPending,
+	OrderStatusPaid,
+	OrderStatusProcessing,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusCancelled,
+	OrderStatusRefunded,
+	OrderStatusFailed,
+}
+
+func TestCanTransitionOrderStatus(t *testing.T) {
+	allowed := map[[2]string]bool{
+		{OrderStatusPending, OrderStatusPaid}:        true,
+		{OrderStatusPending, OrderStatusCancelled}:   true,
+		{OrderStatusPending, OrderStatusFailed}:      true,
+		{OrderStatusPaid, OrderStatusProcessing}:     true,
+		{OrderStatusPaid, OrderStatusRefunded}:       true,
+		{OrderStatusProcessing, OrderStatusShipped}:  true,
+		{OrderStatusProcessing, OrderStatusRefunded}: true,
+		{OrderStatusShipped, OrderStatusDelivered}:   true,
+		{OrderStatusDelivered, OrderStatusRefunded}:  true,
+	}
+
+	for _, from := range orderStatuses {
+		for _, to := range orderStatuses {
+			want := allowed[[2]string{from, to}]
+			t.Run(from+"->"+to, func(t *testing.T) {
+				if got := CanTransitionOrderStatus(from, to); got != want {
+					t.Errorf("CanTransitionOrderStatus(%q, %q) = %v, want %v", from, to, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestCanTransitionOrderStatusUnknown(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+	}{
+		{"unknown from", "archived", OrderStatusPaid},
+		{"unknown to", OrderStatusPending, "archived"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if CanTransitionOrderStatus(tt.from, tt.to) {
+				t.Errorf("CanTransitionOrderStatus(%q, %q) = true, want false", tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestIsValidOrderStatus(t *testing.T) {
+	for _, status := range orderStatuses {
+		if !IsValidOrderStatus(status) {
+			t.Errorf("IsValidOrderStatus(%q) = false, want true", status)
+		}
+	}
+	for _, status := range []string{"", "archived", "Pending"} {
+		if IsValidOrderStatus(status) {
+			t.Errorf("IsValidOrderStatus(%q) = true, want false", status)
+		}
+	}
+}
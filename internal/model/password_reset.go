@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+)
+
+var ErrPasswordResetTokenInvalid = NewError(KindInvalidArgument, "password_reset_token_invalid", "password reset token is invalid or expired")
+
+// PasswordResetToken lets the holder set a new password once. Only the hash
+// of the token is stored.
+type PasswordResetToken struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=3"`
+}
@@ -0,0 +1,117 @@
+package model
+
+const (
+	RoleAdmin    = "admin"
+	RoleCustomer = "customer"
+	RoleSeller   = "seller"
+)
+
+// Permission names an action guarded by the access policy.
+type Permission string
+
+const (
+	PermissionProductsRead    Permission = "products:read"
+	PermissionProductsWrite   Permission = "products:write"
+	PermissionProductsManage  Permission = "products:manage"
+	PermissionCategoriesRead  Permission = "categories:read"
+	PermissionCategoriesWrite Permission = "categories:write"
+	PermissionOrdersRead      Permission = "orders:read"
+	PermissionOrdersWrite     Permission = "orders:write"
+	PermissionOrdersManage    Permission = "orders:manage"
+	PermissionCouponsManage   Permission = "coupons:manage"
+	PermissionUsersManage     Permission = "users:manage"
+)
+
+// rolePermissions is the access policy. "products:write" and the orders
+// permissions only cover the caller's own products and orders; the "manage"
+// permissions extend them to everyone's.
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermissionProductsRead, PermissionProductsWrite, PermissionProductsManage,
+		PermissionCategoriesRead, PermissionCategoriesWrite,
+		PermissionOrdersRead, PermissionOrdersWrite, PermissionOrdersManage,
+		PermissionCouponsManage, PermissionUsersManage,
+	},
+	RoleSeller: {
+		PermissionProductsRead, PermissionProductsWrite,
+		PermissionCategoriesRead,
+		PermissionOrdersRead, PermissionOrdersWrite,
+	},
+	RoleCustomer: {
+		PermissionProductsRead,
+		PermissionCategoriesRead,
+		PermissionOrdersRead, PermissionOrdersWrite,
+	},
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether the caller holds permission. Callers using an API key
+// are further limited to the scopes of the key.
+func (c CustomClaims) Can(permission Permission) bool {
+	if !HasPermission(c.Role, permission) {
+		return false
+	}
+	if c.APIKeyID == 0 {
+		return true
+	}
+
+	for _, scope := range c.Scopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsGrantableScope reports whether permission may be given to an API key.
+// Keys cannot manage users, so they cannot create other keys.
+func IsGrantableScope(permission Permission) bool {
+	if permission == PermissionUsersManage {
+		return false
+	}
+	return HasPermission(RoleAdmin, permission)
+}
+
+// RolesWithPermission lists the roles granted permission.
+func RolesWithPermission(permission Permission) []string {
+	var roles []string
+	for _, role := range []string{RoleAdmin, RoleSeller, RoleCustomer} {
+		if HasPermission(role, permission) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// CanAccessUser reports whether the caller may act on the data of userID.
+func CanAccessUser(claim CustomClaims, userID int64) bool {
+	return claim.UserID == userID || claim.Can(PermissionUsersManage)
+}
+
+// CanAccessOrder reports whether the caller may see or act on order.
+func CanAccessOrder(claim CustomClaims, order *Order) bool {
+	return order.UserID == claim.UserID || claim.Can(PermissionOrdersManage)
+}
+
+// CanManageProduct reports whether the caller may change or delete product.
+// Sellers may only manage the products they listed.
+func CanManageProduct(claim CustomClaims, product *Product) bool {
+	if claim.Can(PermissionProductsManage) {
+		return true
+	}
+	return claim.Can(PermissionProductsWrite) &&
+		product.SellerID != nil && *product.SellerID == claim.UserID
+}
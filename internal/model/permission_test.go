@@ -0,0 +1,172 @@
+package model
+
+import "testing"
+
+var allPermissions = []Permission{
+	PermissionProductsRead,
+	PermissionProductsWrite,
+	PermissionProductsManage,
+	PermissionCategoriesRead,
+	PermissionCategoriesWrite,
+	PermissionOrdersRead,
+	PermissionOrdersWrite,
+	PermissionOrdersManage,
+	PermissionCouponsManage,
+	PermissionUsersManage,
+}
+
+func permissionSet(permissions ...Permission) map[Permission]bool {
+	set := make(map[Permission]bool, len(permissions))
+	for _, p := range permissions {
+		set[p] = true
+	}
+	return set
+}
+
+func TestCustomClaimsCanByRole(t *testing.T) {
+	granted := map[string]map[Permission]bool{
+		RoleAdmin: permissionSet(allPermissions...),
+		RoleSeller: permissionSet(
+			PermissionProductsRead, PermissionProductsWrite,
+			PermissionCategoriesRead,
+			PermissionOrdersRead, PermissionOrdersWrite,
+		),
+		RoleCustomer: permissionSet(
+			PermissionProductsRead,
+			PermissionCategoriesRead,
+			PermissionOrdersRead, PermissionOrdersWrite,
+		),
+		"":          {},
+		"superuser": {},
+	}
+
+	for role, permissions := range granted {
+		for _, permission := range allPermissions {
+			want := permissions[permission]
+			t.Run(role+"/"+string(permission), func(t *testing.T) {
+				claim := CustomClaims{UserID: 1, Role: role}
+				if got := claim.Can(permission); got != want {
+					t.Errorf("%q.Can(%s) = %v, want %v", role, permission, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestCustomClaimsCanWithAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    string
+		scopes  []Permission
+		granted []Permission
+	}{
+		{
+			name:    "no scopes",
+			role:    RoleAdmin,
+			granted: nil,
+		},
+		{
+			name:    "read-only admin key",
+			role:    RoleAdmin,
+			scopes:  []Permission{PermissionProductsRead, PermissionOrdersRead},
+			granted: []Permission{PermissionProductsRead, PermissionOrdersRead},
+		},
+		{
+			name:    "admin key managing orders",
+			role:    RoleAdmin,
+			scopes:  []Permission{PermissionOrdersRead, PermissionOrdersManage},
+			granted: []Permission{PermissionOrdersRead, PermissionOrdersManage},
+		},
+		{
+			// A scope the role does not hold grants nothing.
+			name:    "seller key with manage scopes",
+			role:    RoleSeller,
+			scopes:  []Permission{PermissionProductsWrite, PermissionProductsManage, PermissionUsersManage},
+			granted: []Permission{PermissionProductsWrite},
+		},
+		{
+			name:    "customer key",
+			role:    RoleCustomer,
+			scopes:  []Permission{PermissionOrdersWrite, PermissionOrdersManage},
+			granted: []Permission{PermissionOrdersWrite},
+		},
+	}
+
+	for _, tt := range tests {
+		granted := permissionSet(tt.granted...)
+		for _, permission := range allPermissions {
+			want := granted[permission]
+			t.Run(tt.name+"/"+string(permission), func(t *testing.T) {
+				claim := CustomClaims{UserID: 1, Role: tt.role, APIKeyID: 7, Scopes: tt.scopes}
+				if got := claim.Can(permission); got != want {
+					t.Errorf("Can(%s) = %v, want %v", permission, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestIsGrantableScope(t *testing.T) {
+	for _, permission := range allPermissions {
+		want := permission != PermissionUsersManage
+		if got := IsGrantableScope(permission); got != want {
+			t.Errorf("IsGrantableScope(%s) = %v, want %v", permission, got, want)
+		}
+	}
+	if IsGrantableScope("orders:delete") {
+		t.Error("IsGrantableScope() accepted an unknown permission")
+	}
+}
+
+func TestCanAccessOrder(t *testing.T) {
+	order := &Order{UserID: 1}
+
+	tests := []struct {
+		name  string
+		claim CustomClaims
+		want  bool
+	}{
+		{"owner", CustomClaims{UserID: 1, Role: RoleCustomer}, true},
+		{"other customer", CustomClaims{UserID: 2, Role: RoleCustomer}, false},
+		{"other seller", CustomClaims{UserID: 2, Role: RoleSeller}, false},
+		{"admin", CustomClaims{UserID: 2, Role: RoleAdmin}, true},
+		{"admin key without manage scope", CustomClaims{UserID: 2, Role: RoleAdmin, APIKeyID: 7, Scopes: []Permission{PermissionOrdersRead}}, false},
+		{"admin key with manage scope", CustomClaims{UserID: 2, Role: RoleAdmin, APIKeyID: 7, Scopes: []Permission{PermissionOrdersManage}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanAccessOrder(tt.claim, order); got != tt.want {
+				t.Errorf("CanAccessOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanManageProduct(t *testing.T) {
+	sellerID := int64(1)
+	listed := &Product{SellerID: &sellerID}
+	unlisted := &Product{}
+
+	tests := []struct {
+		name    string
+		claim   CustomClaims
+		product *Product
+		want    bool
+	}{
+		{"own listing", CustomClaims{UserID: 1, Role: RoleSeller}, listed, true},
+		{"other seller's listing", CustomClaims{UserID: 2, Role: RoleSeller}, listed, false},
+		{"product without seller", CustomClaims{UserID: 1, Role: RoleSeller}, unlisted, false},
+		{"customer", CustomClaims{UserID: 1, Role: RoleCustomer}, listed, false},
+		{"admin", CustomClaims{UserID: 2, Role: RoleAdmin}, unlisted, true},
+		{"seller key without write scope", CustomClaims{UserID: 1, Role: RoleSeller, APIKeyID: 7, Scopes: []Permission{PermissionProductsRead}}, listed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanManageProduct(tt.claim, tt.product); got != tt.want {
+				t.Errorf("CanManageProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
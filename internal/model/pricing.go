@@ -0,0 +1,22 @@
+package model
+
+import "context"
+
+// TaxCalculator returns the tax owed on an order. It is called after the
+// coupon discount has been spread over the order items.
+type TaxCalculator interface {
+	CalculateTax(ctx context.Context, order *Order) (Money, error)
+}
+
+// ShippingRateProvider returns the shipping fee for an order. It is called
+// after discounts have been applied.
+type ShippingRateProvider interface {
+	ShippingRate(ctx context.Context, order *Order) (Money, error)
+}
+
+// ShippingTier charges Fee on orders whose discounted subtotal is at least
+// MinSubtotal.
+type ShippingTier struct {
+	MinSubtotal Money
+	Fee         Money
+}
@@ -2,16 +2,40 @@ package model
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
+var (
+	ErrProductNotFound   = NewError(KindNotFound, "product_not_found", "product not found")
+	ErrInsufficientStock = NewError(KindFailedPrecondition, "insufficient_stock", "insufficient stock")
+	ErrInvalidProduct    = NewError(KindInvalidArgument, "invalid_product", "invalid product data")
+)
+
+// InsufficientStockError is returned when an order asks for more units of a
+// product than are currently in stock. It matches ErrInsufficientStock with
+// errors.Is.
+type InsufficientStockError struct {
+	ProductID int64
+	Requested int64
+	Available int64
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for product %d: requested %d, available %d", e.ProductID, e.Requested, e.Available)
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
 type IProductRepository interface {
 	FindAll(ctx context.Context, filter FindAllParam) ([]*Product, error)
 	FindById(ctx context.Context, id int64) (*Product, error)
 	Create(ctx context.Context, product Product) error
 	Update(ctx context.Context, product Product) error
 	Delete(ctx context.Context, id int64) error
-	GetPriceByID(ctx context.Context, productID int64, price *float64) error
+	GetPriceByID(ctx context.Context, productID int64, price *Money) error
 }
 
 type IProductUsecase interface {
@@ -26,10 +50,12 @@ type Product struct {
 	ID           int64      `json:"id"`
 	Name         string     `json:"name"`
 	Description  string     `json:"description"`
-	Price        float64    `json:"price"`
+	Price        Money      `json:"price"`
+	Currency     string     `json:"currency"`
 	Stock        int64      `json:"stock"`
 	CategoryID   int64      `json:"category_id"`
 	CategoryName string     `json:"category_name,omitempty"`
+	SellerID     *int64     `json:"seller_id,omitempty"`
 	ImageUrl     string     `json:"image_url"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
@@ -42,19 +68,24 @@ type FindAllParam struct {
 }
 
 type CreateProductInput struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description" validate:"required"`
-	Price       float64 `json:"price" validate:"required"`
-	Stock       int64   `json:"stock" validate:"required"`
-	CategoryID  int64   `json:"category_id" validate:"required"`
-	ImageUrl    string  `json:"image_url" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description" validate:"required"`
+	Price       Money  `json:"price" validate:"required"`
+	Currency    string `json:"currency" validate:"omitempty,iso4217,money_currency"`
+	Stock       int64  `json:"stock" validate:"required"`
+	CategoryID  int64  `json:"category_id" validate:"required"`
+	ImageUrl    string `json:"image_url" validate:"required"`
+
+	// SellerID is set from the caller when a seller lists a product.
+	SellerID *int64 `json:"-"`
 }
 
 type UpdateProductInput struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description" validate:"required"`
-	Price       float64 `json:"price" validate:"required"`
-	Stock       int64   `json:"stock" validate:"required"`
-	CategoryID  int64   `json:"category_id" validate:"required"`
-	ImageUrl    string  `json:"image_url" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description" validate:"required"`
+	Price       Money  `json:"price" validate:"required"`
+	Currency    string `json:"currency" validate:"omitempty,iso4217,money_currency"`
+	Stock       int64  `json:"stock" validate:"required"`
+	CategoryID  int64  `json:"category_id" validate:"required"`
+	ImageUrl    string `json:"image_url" validate:"required"`
 }
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	SellerApplicationPending  = "pending"
+	SellerApplicationApproved = "approved"
+	SellerApplicationRejected = "rejected"
+)
+
+var (
+	ErrForbidden                     = NewError(KindForbidden, "forbidden", "access denied")
+	ErrInvalidRole                   = NewError(KindInvalidArgument, "invalid_role", "invalid role")
+	ErrCannotChangeOwnRole           = NewError(KindForbidden, "cannot_change_own_role", "users cannot change their own role")
+	ErrSellerApplicationNotFound     = NewError(KindNotFound, "seller_application_not_found", "seller application not found")
+	ErrSellerApplicationExists       = NewError(KindAlreadyExists, "seller_application_exists", "a seller application is already pending")
+	ErrSellerApplicationReviewed     = NewError(KindFailedPrecondition, "seller_application_reviewed", "seller application has already been reviewed")
+	ErrSellerApplicationNotAvailable = NewError(KindForbidden, "seller_application_not_available", "only customers can apply to become sellers")
+)
+
+type IRoleRepository interface {
+	// ChangeRole sets the user's role and records change in the same
+	// transaction. change.FromRole is filled from the current role; when it
+	// equals change.ToRole nothing is written.
+	ChangeRole(ctx context.Context, change *RoleChange) error
+	FindRoleChanges(ctx context.Context, userID int64) ([]*RoleChange, error)
+	CreateSellerApplication(ctx context.Context, application *SellerApplication) error
+	FindSellerApplications(ctx context.Context, status string) ([]*SellerApplication, error)
+	FindSellerApplicationById(ctx context.Context, id int64) (*SellerApplication, error)
+	// ReviewSellerApplication closes a pending application. When it is
+	// approved, the applicant's role is changed as recorded in change; an
+	// applicant who is no longer a customer gets
+	// ErrSellerApplicationNotAvailable and the application stays pending.
+	ReviewSellerApplication(ctx context.Context, application SellerApplication, change *RoleChange) error
+}
+
+type IRoleUsecase interface {
+	ChangeRole(ctx context.Context, userID int64, in ChangeRoleInput) (*RoleChange, error)
+	FindRoleChanges(ctx context.Context, userID int64) ([]*RoleChange, error)
+	ApplyForSeller(ctx context.Context, userID int64, in SellerApplicationInput) (*SellerApplication, error)
+	FindSellerApplications(ctx context.Context, status string) ([]*SellerApplication, error)
+	ApproveSellerApplication(ctx context.Context, id int64, in ReviewSellerApplicationInput) (*SellerApplication, error)
+	RejectSellerApplication(ctx context.Context, id int64, in ReviewSellerApplicationInput) (*SellerApplication, error)
+}
+
+// RoleChange is an audit entry for a change of a user's role.
+type RoleChange struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"user_id"`
+	FromRole     string    `json:"from_role"`
+	ToRole       string    `json:"to_role"`
+	ActorUserID  *int64    `json:"actor_user_id,omitempty"`
+	ActorService string    `json:"actor_service,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (RoleChange) TableName() string {
+	return "user_role_changes"
+}
+
+type SellerApplication struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	StoreName  string     `json:"store_name"`
+	Note       string     `json:"note,omitempty"`
+	Status     string     `json:"status"`
+	ReviewedBy *int64     `json:"reviewed_by,omitempty"`
+	ReviewNote string     `json:"review_note,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type ChangeRoleInput struct {
+	Role   string `json:"role" validate:"required,oneof=admin customer seller"`
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+type SellerApplicationInput struct {
+	StoreName string `json:"store_name" validate:"required,max=255"`
+	Note      string `json:"note" validate:"max=1000"`
+}
+
+type ReviewSellerApplicationInput struct {
+	Note string `json:"note" validate:"max=1000"`
+}
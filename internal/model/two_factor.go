@@ -0,0 +1,130 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	ErrTwoFactorNotEnrolled       = NewError(KindFailedPrecondition, "two_factor_not_enrolled", "two-factor authentication is not enrolled")
+	ErrTwoFactorAlreadyEnabled    = NewError(KindFailedPrecondition, "two_factor_already_enabled", "two-factor authentication is already enabled")
+	ErrTwoFactorRequired          = NewError(KindForbidden, "two_factor_required", "two-factor authentication is required for this account")
+	ErrInvalidTwoFactorCode       = NewError(KindUnauthenticated, "invalid_two_factor_code", "invalid two-factor code")
+	ErrLoginChallengeInvalid      = NewError(KindUnauthenticated, "login_challenge_invalid", "login challenge is invalid or expired")
+	ErrTwoFactorEnrollmentPending = NewError(KindFailedPrecondition, "two_factor_enrollment_pending", "two-factor enrollment must be completed first")
+)
+
+type ITwoFactorRepository interface {
+	// FindTOTP returns nil when the user has not started enrollment.
+	FindTOTP(ctx context.Context, userID int64) (*UserTOTP, error)
+	// SaveTOTP starts a new enrollment, replacing any unconfirmed one.
+	SaveTOTP(ctx context.Context, totp UserTOTP) error
+	// ConfirmTOTP enables the enrollment and replaces the recovery codes.
+	ConfirmTOTP(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error
+	// UseTOTPStep records step as used and reports false when it, or a
+	// later step, was used before.
+	UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error)
+	DeleteTOTP(ctx context.Context, userID int64) error
+	// UseRecoveryCode marks the code used and reports false when it is
+	// unknown or already used.
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+
+	CreateLoginChallenge(ctx context.Context, challenge LoginChallenge) error
+	FindLoginChallenge(ctx context.Context, tokenHash string) (*LoginChallenge, error)
+	// FailLoginChallenge counts a wrong code, deleting the challenge once
+	// maxAttempts is reached.
+	FailLoginChallenge(ctx context.Context, id int64, maxAttempts int64) error
+	DeleteLoginChallenge(ctx context.Context, id int64) error
+}
+
+// UserTOTP is a user's authenticator enrollment. The secret is stored
+// encrypted; the enrollment only counts once ConfirmedAt is set.
+type UserTOTP struct {
+	UserID          int64      `json:"user_id" gorm:"primaryKey"`
+	SecretEncrypted string     `json:"-"`
+	ConfirmedAt     *time.Time `json:"confirmed_at"`
+	LastUsedStep    int64      `json:"-"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func (UserTOTP) TableName() string {
+	return "user_totp"
+}
+
+// RecoveryCode is a single-use code that stands in for a TOTP code. Only
+// its hash is stored.
+type RecoveryCode struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	CodeHash  string     `json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
+
+// LoginChallenge is issued when the password was right but a second factor
+// is still needed. Only the hash of its token is stored.
+type LoginChallenge struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	TokenHash string    `json:"-"`
+	Attempts  int64     `json:"attempts"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TwoFactorPolicy configures TOTP two-factor authentication.
+type TwoFactorPolicy struct {
+	// Issuer names the service in authenticator apps.
+	Issuer string
+	// EncryptionKey encrypts TOTP secrets at rest; 32 bytes.
+	EncryptionKey []byte
+	ChallengeTTL  time.Duration
+	// MaxChallengeAttempts wrong codes end a login challenge.
+	MaxChallengeAttempts int64
+	// RequireForAdmins makes admins enroll before they can log in.
+	RequireForAdmins bool
+}
+
+// LoginResult holds either the session tokens or, when a second factor is
+// needed, the challenge to complete.
+type LoginResult struct {
+	Tokens    *TokenPair
+	Challenge *TwoFactorChallenge
+	// RecoveryCodes is set once, when login completed a first enrollment.
+	RecoveryCodes []string
+}
+
+type TwoFactorChallenge struct {
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	// EnrollmentRequired means the account must enroll an authenticator,
+	// with the challenge token, before it can log in.
+	EnrollmentRequired bool `json:"enrollment_required"`
+}
+
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TwoFactorLoginInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	// Code is a TOTP code or, instead, one of the recovery codes.
+	Code string `json:"code" validate:"required"`
+
+	// Recorded on the session; filled from the request, not the body.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+type TwoFactorChallengeInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
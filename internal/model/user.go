@@ -9,11 +9,40 @@ import (
 
 type ContextAuthKey string
 
-const BearerAuthKey ContextAuthKey = "BearerAuth"
+const (
+	BearerAuthKey   ContextAuthKey = "BearerAuth"
+	ActorServiceKey ContextAuthKey = "ActorService"
+)
+
+// ActorFromContext identifies who is acting on behalf of ctx: the
+// authenticated user when there is one, otherwise the calling service.
+func ActorFromContext(ctx context.Context) (userID *int64, service string) {
+	if claim, ok := ctx.Value(BearerAuthKey).(CustomClaims); ok && claim.UserID != 0 {
+		id := claim.UserID
+		userID = &id
+	}
+
+	service, _ = ctx.Value(ActorServiceKey).(string)
+	if userID == nil && service == "" {
+		service = "system"
+	}
+
+	return userID, service
+}
+
+var (
+	ErrUserNotFound        = NewError(KindNotFound, "user_not_found", "user not found")
+	ErrInvalidUserID       = NewError(KindInvalidArgument, "invalid_user_id", "invalid user ID")
+	ErrSessionNotFound     = NewError(KindNotFound, "session_not_found", "session not found or expired")
+	ErrRefreshTokenInvalid = NewError(KindUnauthenticated, "refresh_token_invalid", "refresh token is invalid or expired")
+	ErrRefreshTokenReused  = NewError(KindUnauthenticated, "refresh_token_reused", "refresh token was already used")
+)
 
 type IUserRepository interface {
 	FindAll(ctx context.Context, user User) ([]*User, error)
 	FindById(ctx context.Context, id int64) (*User, error)
+	// FindByEmail returns nil when no user, or only a deleted one, has
+	// email.
 	FindByEmail(ctx context.Context, email string) *User
 	Create(ctx context.Context, user User) (*User, error)
 	Update(ctx context.Context, user User) error
@@ -21,59 +50,149 @@ type IUserRepository interface {
 	CreateSession(ctx context.Context, session UserSession) (*UserSession, error)
 	FindSessionByToken(ctx context.Context, token string) (*UserSession, error)
 	DeleteSession(ctx context.Context, token string) error
+	FindSessionsByUserID(ctx context.Context, userID int64) ([]*UserSession, error)
+	// DeleteSessionByID returns the deleted session, or ErrSessionNotFound
+	// when userID has no such session.
+	DeleteSessionByID(ctx context.Context, userID int64, id int64) (*UserSession, error)
+	// DeleteSessionsByUserID deletes all of the user's sessions except
+	// exceptID, and returns the deleted ones.
+	DeleteSessionsByUserID(ctx context.Context, userID int64, exceptID int64) ([]*UserSession, error)
+	TouchSession(ctx context.Context, id int64, lastSeenAt time.Time) error
+	// CreatePasswordResetToken saves token and invalidates the user's
+	// earlier unused ones.
+	CreatePasswordResetToken(ctx context.Context, token PasswordResetToken) error
+	// ResetPassword consumes the reset token with tokenHash, sets the new
+	// password and deletes every session of the user, returning them.
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) ([]*UserSession, error)
+	// CreateEmailVerificationToken saves token and invalidates the user's
+	// earlier unused ones.
+	CreateEmailVerificationToken(ctx context.Context, token EmailVerificationToken) error
+	// FindLatestEmailVerificationToken returns nil when none was issued.
+	FindLatestEmailVerificationToken(ctx context.Context, userID int64) (*EmailVerificationToken, error)
+	// VerifyEmail consumes the token with tokenHash and marks the email of
+	// its user as verified.
+	VerifyEmail(ctx context.Context, tokenHash string) error
+	CreateRefreshToken(ctx context.Context, token RefreshToken) error
+	FindRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// RotateRefreshToken marks the token with tokenHash as used, saves next
+	// in the same session and moves the session to accessToken. Presenting
+	// an already used token revokes the whole session and returns
+	// ErrRefreshTokenReused. Either way the access token the session had
+	// before is returned, so it can be dropped from caches.
+	RotateRefreshToken(ctx context.Context, tokenHash string, next RefreshToken, accessToken string) (previousAccessToken string, err error)
 }
 
 type IUserUsecase interface {
 	FindAll(ctx context.Context, user User) ([]*User, error)
 	FindById(ctx context.Context, id int64) (*User, error)
-	Create(ctx context.Context, in CreateUserInput) (token string, err error)
+	Create(ctx context.Context, in CreateUserInput) (*TokenPair, error)
 	Update(ctx context.Context, id int64, in UpdateUserInput) error
 	Delete(ctx context.Context, id int64) error
 	ValidateSession(ctx context.Context, token string) (*UserSession, error)
-	Login(ctx context.Context, in LoginInput) (token string, err error)
+	Login(ctx context.Context, in LoginInput) (*LoginResult, error)
+	CompleteLogin(ctx context.Context, in TwoFactorLoginInput) (*LoginResult, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
 	Logout(ctx context.Context, token string) error
+	ListSessions(ctx context.Context, userID int64, currentToken string) ([]*UserSession, error)
+	RevokeSession(ctx context.Context, userID int64, sessionID int64) error
+	RevokeOtherSessions(ctx context.Context, userID int64, currentToken string) error
+	RevokeAllSessions(ctx context.Context, userID int64) error
+	ForgotPassword(ctx context.Context, in ForgotPasswordInput) error
+	ResetPassword(ctx context.Context, in ResetPasswordInput) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerificationEmail(ctx context.Context, userID int64) error
+	UnlockAccount(ctx context.Context, userID int64) error
+	EnrollTOTP(ctx context.Context, userID int64) (*TOTPEnrollment, error)
+	EnrollTOTPWithChallenge(ctx context.Context, in TwoFactorChallengeInput) (*TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int64, in TwoFactorCodeInput) ([]string, error)
+	DisableTOTP(ctx context.Context, userID int64, in TwoFactorCodeInput) error
+	// WaitForMail blocks until the emails still being sent in the
+	// background are done, or ctx is.
+	WaitForMail(ctx context.Context) error
 }
 
 type CustomClaims struct {
-	UserID int64 `json:"user_id"`
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
 	jwt.RegisteredClaims
+
+	// Set when the caller authenticated with an API key instead of a
+	// token; the key only grants Scopes.
+	APIKeyID int64        `json:"-"`
+	Scopes   []Permission `json:"-"`
 }
 
 type User struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+	// EmailVerifiedAt is nil until the user follows the verification link.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"-"`
+}
+
+type UserSession struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Token      string     `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// Current marks the session of the request that listed the sessions.
+	Current bool `json:"current" gorm:"-"`
+}
+
+// RefreshToken is a single-use token that renews a session. Only the hash
+// of the token is stored. Every token issued for a session belongs to the
+// same family, identified by the session.
+type RefreshToken struct {
 	ID        int64      `json:"id"`
-	Name      string     `json:"name"`
-	Email     string     `json:"email"`
-	Password  string     `json:"password"`
-	Role      string     `json:"role"`
+	UserID    int64      `json:"user_id"`
+	SessionID int64      `json:"session_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
 	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	DeletedAt *time.Time `json:"-"`
 }
 
-type UserSession struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
 }
 
 // validation
 type LoginInput struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required"`
+
+	// Recorded on the session; filled from the request, not the body.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 type CreateUserInput struct {
 	Name     string `json:"name" validate:"required"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=3"`
-	Role     string `json:"role" validate:"required"`
+
+	// Recorded on the session; filled from the request, not the body.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
 }
 
 type UpdateUserInput struct {
 	Name     string `json:"name" validate:"required"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=3"`
-	Role     string `json:"role" validate:"required"`
 }
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type AddressRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressRepo(db *gorm.DB) model.IAddressRepository {
+	return &AddressRepository{db: db}
+}
+
+func (r *AddressRepository) FindAllByUserID(ctx context.Context, userID int64) ([]*model.UserAddress, error) {
+	var addresses []*model.UserAddress
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Order("is_default DESC, id ASC").
+		Find(&addresses).Error
+	if err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+func (r *AddressRepository) FindById(ctx context.Context, userID int64, id int64) (*model.UserAddress, error) {
+	var address model.UserAddress
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ? AND deleted_at IS NULL", id, userID).
+		First(&address).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (r *AddressRepository) FindDefault(ctx context.Context, userID int64) (*model.UserAddress, error) {
+	var address model.UserAddress
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND is_default AND deleted_at IS NULL", userID).
+		First(&address).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// Create saves a new address. The user's first address always becomes the
+// default one.
+func (r *AddressRepository) Create(ctx context.Context, address *model.UserAddress) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&model.UserAddress{}).
+			Where("user_id = ? AND deleted_at IS NULL", address.UserID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			address.IsDefault = true
+		}
+
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address.UserID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(address).Error
+	})
+}
+
+func (r *AddressRepository) Update(ctx context.Context, address model.UserAddress) error {
+	address.UpdatedAt = time.Now()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address.UserID); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&model.UserAddress{}).
+			Where("id = ? AND user_id = ? AND deleted_at IS NULL", address.ID, address.UserID).
+			Select("label", "recipient_name", "phone", "line1", "line2", "city", "province",
+				"postal_code", "country_code", "is_default", "updated_at").
+			Updates(&address)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrAddressNotFound
+		}
+		return nil
+	})
+}
+
+func (r *AddressRepository) Delete(ctx context.Context, userID int64, id int64) error {
+	result := r.db.WithContext(ctx).
+		Model(&model.UserAddress{}).
+		Where("id = ? AND user_id = ? AND deleted_at IS NULL", id, userID).
+		Updates(map[string]interface{}{
+			"is_default": false,
+			"deleted_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrAddressNotFound
+	}
+	return nil
+}
+
+func clearDefaultAddress(tx *gorm.DB, userID int64) error {
+	return tx.Model(&model.UserAddress{}).
+		Where("user_id = ? AND is_default", userID).
+		Update("is_default", false).Error
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepo(db *gorm.DB) model.IAPIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) FindAll(ctx context.Context, ownerID int64) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	query := r.db.WithContext(ctx).Order("id ASC")
+	if ownerID != 0 {
+		query = query.Where("owner_id = ?", ownerID)
+	}
+
+	if err := query.Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepository) FindById(ctx context.Context, id int64) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *APIKeyRepository) Update(ctx context.Context, key model.APIKey) error {
+	key.UpdatedAt = time.Now()
+
+	// expires_at is listed so that it can be cleared.
+	result := r.db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ?", key.ID).
+		Select("name", "scopes", "expires_at", "updated_at").
+		Updates(&key)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) Delete(ctx context.Context, id int64) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time, interval time.Duration) error {
+	return r.db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, usedAt.Add(-interval)).
+		Update("last_used_at", usedAt).Error
+}
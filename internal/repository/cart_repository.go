@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CartRepository struct {
+	db *gorm.DB
+}
+
+func NewCartRepo(db *gorm.DB) model.ICartRepository {
+	return &CartRepository{db: db}
+}
+
+func (r *CartRepository) FindOrCreate(ctx context.Context, userID int64) (*model.Cart, error) {
+	now := time.Now()
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.Cart{UserID: userID, CreatedAt: now, UpdatedAt: now}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var cart model.Cart
+	err = r.db.WithContext(ctx).
+		Preload("CartItems", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Where("user_id = ?", userID).
+		First(&cart).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (r *CartRepository) AddItem(ctx context.Context, item model.CartItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		item.CreatedAt = time.Now()
+		item.UpdatedAt = time.Now()
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "cart_id"}, {Name: "product_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"quantity":   gorm.Expr("cart_items.quantity + EXCLUDED.quantity"),
+				"price":      gorm.Expr("EXCLUDED.price"),
+				"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+			}),
+		}).Create(&item).Error
+		if err != nil {
+			return err
+		}
+
+		return touchCart(tx, item.CartID)
+	})
+}
+
+func (r *CartRepository) UpdateItem(ctx context.Context, item model.CartItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.CartItem{}).
+			Where("cart_id = ? AND product_id = ?", item.CartID, item.ProductID).
+			Updates(map[string]interface{}{
+				"quantity":   item.Quantity,
+				"price":      item.Price,
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrCartItemNotFound
+		}
+
+		return touchCart(tx, item.CartID)
+	})
+}
+
+func (r *CartRepository) DeleteItem(ctx context.Context, cartID int64, productID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("cart_id = ? AND product_id = ?", cartID, productID).Delete(&model.CartItem{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrCartItemNotFound
+		}
+
+		return touchCart(tx, cartID)
+	})
+}
+
+func (r *CartRepository) RemoveCheckedOut(ctx context.Context, cart model.Cart) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current model.Cart
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "updated_at").
+			Where("id = ?", cart.ID).
+			First(&current).Error
+		if err != nil {
+			return err
+		}
+
+		query := tx.Where("cart_id = ?", cart.ID)
+		if !current.UpdatedAt.Equal(cart.UpdatedAt) {
+			// The cart changed after the snapshot: remove only the rows
+			// that are still exactly as they were ordered. Re-added items
+			// get new IDs, so a replayed checkout cannot remove them.
+			rows := make([][]interface{}, 0, len(cart.CartItems))
+			for _, item := range cart.CartItems {
+				rows = append(rows, []interface{}{item.ID, item.ProductID, item.Quantity})
+			}
+			query = query.Where("(id, product_id, quantity) IN ?", rows)
+		}
+		if err := query.Delete(&model.CartItem{}).Error; err != nil {
+			return err
+		}
+
+		return touchCart(tx, cart.ID)
+	})
+}
+
+// touchCart bumps the cart's updated_at, which also serves as its version.
+func touchCart(tx *gorm.DB, cartID int64) error {
+	return tx.Model(&model.Cart{}).
+		Where("id = ?", cartID).
+		Update("updated_at", time.Now()).Error
+}
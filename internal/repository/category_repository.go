@@ -29,7 +29,7 @@ func (r *CategoryRepository) FindById(ctx context.Context, id int64) (*model.Cat
 	err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&category).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, nil
+			return nil, model.ErrCategoryNotFound
 		}
 		return nil, err
 	}
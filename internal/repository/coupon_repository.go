@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CouponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepo(db *gorm.DB) model.ICouponRepository {
+	return &CouponRepository{db: db}
+}
+
+func (r *CouponRepository) FindAll(ctx context.Context) ([]*model.Coupon, error) {
+	var coupons []*model.Coupon
+	err := r.db.WithContext(ctx).
+		Where("deleted_at IS NULL").
+		Order("id ASC").
+		Find(&coupons).Error
+	if err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func (r *CouponRepository) FindById(ctx context.Context, id int64) (*model.Coupon, error) {
+	var coupon model.Coupon
+	err := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&coupon).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *CouponRepository) FindByCode(ctx context.Context, code string) (*model.Coupon, error) {
+	var coupon model.Coupon
+	err := r.db.WithContext(ctx).
+		Where("code = ? AND deleted_at IS NULL", strings.ToUpper(code)).
+		First(&coupon).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *CouponRepository) Create(ctx context.Context, coupon *model.Coupon) error {
+	coupon.Code = strings.ToUpper(coupon.Code)
+	return r.db.WithContext(ctx).Create(coupon).Error
+}
+
+func (r *CouponRepository) Update(ctx context.Context, coupon model.Coupon) error {
+	coupon.Code = strings.ToUpper(coupon.Code)
+	coupon.UpdatedAt = time.Now()
+
+	// Every editable column is listed so that limits and scopes can be
+	// cleared back to their zero values.
+	return r.db.WithContext(ctx).
+		Model(&model.Coupon{}).
+		Where("id = ? AND deleted_at IS NULL", coupon.ID).
+		Select("code", "type", "percent_off", "amount_off", "currency", "max_discount",
+			"min_order_amount", "usage_limit", "per_user_limit", "starts_at", "ends_at",
+			"product_ids", "category_ids", "updated_at").
+		Updates(&coupon).Error
+}
+
+func (r *CouponRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Coupon{}).
+		Where("id = ?", id).
+		Update("deleted_at", gorm.Expr("NOW()")).Error
+}
+
+func (r *CouponRepository) CountRedemptionsByUser(ctx context.Context, couponID int64, userID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.CouponRedemption{}).
+		Where("coupon_id = ? AND user_id = ?", couponID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+// redeemCoupon records the order's use of its coupon. The coupon row is
+// locked so usage limits hold under concurrent checkouts.
+func redeemCoupon(tx *gorm.DB, order *model.Order) error {
+	var coupon model.Coupon
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND deleted_at IS NULL", *order.CouponID).
+		First(&coupon).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.ErrCouponNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if coupon.UsageLimit != nil && coupon.UsedCount >= *coupon.UsageLimit {
+		return model.ErrCouponUsageExhausted
+	}
+
+	if coupon.PerUserLimit != nil {
+		var used int64
+		err := tx.Model(&model.CouponRedemption{}).
+			Where("coupon_id = ? AND user_id = ?", coupon.ID, order.UserID).
+			Count(&used).Error
+		if err != nil {
+			return err
+		}
+		if used >= *coupon.PerUserLimit {
+			return model.ErrCouponUsageExhausted
+		}
+	}
+
+	err = tx.Model(&model.Coupon{}).
+		Where("id = ?", coupon.ID).
+		Update("used_count", gorm.Expr("used_count + 1")).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&model.CouponRedemption{
+		CouponID:       coupon.ID,
+		UserID:         order.UserID,
+		OrderID:        order.ID,
+		DiscountAmount: order.DiscountAmount,
+		CreatedAt:      time.Now(),
+	}).Error
+}
+
+// releaseCoupon gives back the coupon usage held by an order, if any.
+func releaseCoupon(tx *gorm.DB, orderID string) error {
+	var redemption model.CouponRedemption
+	err := tx.Where("order_id = ?", orderID).First(&redemption).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Delete(&redemption).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&model.Coupon{}).
+		Where("id = ?", redemption.CouponID).
+		Update("used_count", gorm.Expr("used_count - 1")).Error
+}
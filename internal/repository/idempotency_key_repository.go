@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyKeyRepo(db *gorm.DB) model.IIdempotencyKeyRepository {
+	return &IdempotencyKeyRepository{db: db}
+}
+
+func (r *IdempotencyKeyRepository) Reserve(ctx context.Context, record model.IdempotencyKey) (*model.IdempotencyKey, bool, error) {
+	db := r.db.WithContext(ctx)
+
+	// An expired key is free to be claimed by a new request.
+	err := db.Where("user_id = ? AND key = ? AND expires_at <= ?", record.UserID, record.Key, time.Now()).
+		Delete(&model.IdempotencyKey{}).Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	record.CreatedAt = time.Now()
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return &record, true, nil
+	}
+
+	var existing model.IdempotencyKey
+	err = db.Where("user_id = ? AND key = ?", record.UserID, record.Key).First(&existing).Error
+	if err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+func (r *IdempotencyKeyRepository) Complete(ctx context.Context, userID int64, key string, orderID string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", userID, key).
+		Update("order_id", orderID).Error
+}
+
+func (r *IdempotencyKeyRepository) Release(ctx context.Context, userID int64, key string) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND key = ? AND order_id IS NULL", userID, key).
+		Delete(&model.IdempotencyKey{}).Error
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginAttemptRepo struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepo(db *gorm.DB) model.ILoginAttemptRepository {
+	return &LoginAttemptRepo{db: db}
+}
+
+func (r *LoginAttemptRepo) FindByKeys(ctx context.Context, keys []model.LoginAttemptKey) ([]*model.LoginAttempt, error) {
+	var attempts []*model.LoginAttempt
+	if len(keys) == 0 {
+		return attempts, nil
+	}
+
+	pairs := make([][]interface{}, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, []interface{}{key.Scope, key.Key})
+	}
+
+	err := r.db.WithContext(ctx).Where("(scope, key) IN ?", pairs).Find(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// loginAttemptRetryAt is LoginThrottlePolicy.RetryAt in SQL, without the
+// lockout, which is checked on its own. Its arguments are the free attempts,
+// the backoff base, the free attempts again and the backoff cap, durations
+// in microseconds.
+const loginAttemptRetryAt = `login_attempts.last_failure_at + CASE
+	WHEN login_attempts.failures > ? THEN LEAST(? * power(2, LEAST(login_attempts.failures - ? - 1, 32)), ?)
+	ELSE 0
+END * interval '1 microsecond'`
+
+func (r *LoginAttemptRepo) TryAttempt(ctx context.Context, key model.LoginAttemptKey, policy model.LoginThrottlePolicy) (*model.LoginAttempt, bool, error) {
+	now := time.Now()
+	windowStart := now.Add(-policy.FailureWindow)
+	attempt := model.LoginAttempt{
+		Scope:         key.Scope,
+		Key:           key.Key,
+		Failures:      1,
+		LastFailureAt: now,
+	}
+
+	result := r.db.WithContext(ctx).
+		Clauses(
+			clause.OnConflict{
+				Columns: []clause.Column{{Name: "scope"}, {Name: "key"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"failures": gorm.Expr(
+						"CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END",
+						windowStart),
+					"last_failure_at": now,
+				}),
+				Where: clause.Where{Exprs: []clause.Expression{
+					gorm.Expr("login_attempts.locked_until IS NULL OR login_attempts.locked_until <= ?", now),
+					gorm.Expr("login_attempts.last_failure_at < ? OR "+loginAttemptRetryAt+" <= ?",
+						windowStart,
+						policy.FreeAttempts, policy.BackoffBase.Microseconds(), policy.FreeAttempts, policy.BackoffMax.Microseconds(),
+						now),
+				}},
+			},
+			clause.Returning{},
+		).
+		Create(&attempt)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, false, nil
+	}
+	return &attempt, true, nil
+}
+
+func (r *LoginAttemptRepo) ReleaseAttempt(ctx context.Context, key model.LoginAttemptKey) error {
+	return r.db.WithContext(ctx).
+		Model(&model.LoginAttempt{}).
+		Where("scope = ? AND key = ? AND failures > 0", key.Scope, key.Key).
+		Update("failures", gorm.Expr("failures - 1")).Error
+}
+
+func (r *LoginAttemptRepo) Lock(ctx context.Context, key model.LoginAttemptKey, until time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.LoginAttempt{}).
+		Where("scope = ? AND key = ?", key.Scope, key.Key).
+		Update("locked_until", until).Error
+}
+
+func (r *LoginAttemptRepo) Reset(ctx context.Context, key model.LoginAttemptKey) error {
+	return r.db.WithContext(ctx).
+		Where("scope = ? AND key = ?", key.Scope, key.Key).
+		Delete(&model.LoginAttempt{}).Error
+}
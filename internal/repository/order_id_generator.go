@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// DailyOrderIDGenerator numbers orders per calendar day using the
+// order_id_counters table. The counter row is incremented with a single
+// upsert, so concurrent callers always receive distinct sequence numbers.
+type DailyOrderIDGenerator struct {
+	db         *gorm.DB
+	format     string
+	dateLayout string
+	location   *time.Location
+}
+
+// NewDailyOrderIDGenerator builds IDs with fmt.Sprintf(format, date, sequence),
+// where date is the current day in location formatted with dateLayout. The
+// layout must tell calendar days apart, as the sequence restarts daily.
+func NewDailyOrderIDGenerator(db *gorm.DB, format, dateLayout string, location *time.Location) model.OrderIDGenerator {
+	return &DailyOrderIDGenerator{
+		db:         db,
+		format:     format,
+		dateLayout: dateLayout,
+		location:   location,
+	}
+}
+
+func (g *DailyOrderIDGenerator) NextID(ctx context.Context) (string, error) {
+	now := time.Now().In(g.location)
+
+	var sequence int64
+	err := g.db.WithContext(ctx).
+		Raw(`INSERT INTO order_id_counters ("day", "last_value") VALUES (?, 1)
+			ON CONFLICT ("day") DO UPDATE SET "last_value" = order_id_counters.last_value + 1
+			RETURNING "last_value"`, now.Format("2006-01-02")).
+		Scan(&sequence).Error
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(g.format, now.Format(g.dateLayout), sequence), nil
+}
@@ -3,20 +3,25 @@ package repository
 import (
 	"context"
 	"errors"
-	"fmt"
+	"sort"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepository struct {
-	db *gorm.DB
+	db          *gorm.DB
+	idGenerator model.OrderIDGenerator
 }
 
-func NewOrderRepo(db *gorm.DB) model.IOrderRepository {
-	return &OrderRepository{db: db}
+func NewOrderRepo(db *gorm.DB, idGenerator model.OrderIDGenerator) model.IOrderRepository {
+	return &OrderRepository{
+		db:          db,
+		idGenerator: idGenerator,
+	}
 }
 
 func (r *OrderRepository) FindAll(ctx context.Context, userID int64) ([]*model.Order, error) {
@@ -40,7 +45,7 @@ func (r *OrderRepository) FindById(ctx context.Context, id string) (*model.Order
 		First(&order).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, errors.New("order not found")
+		return nil, model.ErrOrderNotFound
 	}
 
 	if err != nil {
@@ -50,6 +55,12 @@ func (r *OrderRepository) FindById(ctx context.Context, id string) (*model.Order
 }
 
 func (r *OrderRepository) SaveOrder(ctx context.Context, order *model.Order) error {
+	orderID, err := r.idGenerator.NextID(ctx)
+	if err != nil {
+		return err
+	}
+	order.ID = orderID
+
 	tx := r.db.WithContext(ctx).Begin()
 	if err := tx.Error; err != nil {
 		return err
@@ -61,24 +72,24 @@ func (r *OrderRepository) SaveOrder(ctx context.Context, order *model.Order) err
 		}
 	}()
 
-	currentDate := time.Now().Format("20060102")
-
-	var count int64
-	if err := tx.Model(&model.Order{}).
-		Where("DATE(created_at) = ?", currentDate).
-		Count(&count).Error; err != nil {
+	if err := reserveStock(tx, order.OrderItems); err != nil {
 		tx.Rollback()
 		return err
 	}
-	order.ID = fmt.Sprintf("ORD-%s-%03d", currentDate, count+1)
 
-	existingOrder := model.Order{}
-	if err := tx.Where("id = ?", order.ID).First(&existingOrder).Error; err == nil {
+	if err := tx.Omit("OrderItems").Create(order).Error; err != nil {
 		tx.Rollback()
-		return errors.New("duplicate order detected in database")
+		return err
+	}
+
+	if order.CouponID != nil {
+		if err := redeemCoupon(tx, order); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 
-	if err := tx.Create(&order).Error; err != nil {
+	if err := recordStatusChange(ctx, tx, order.ID, nil, order.Status, order.StatusReason); err != nil {
 		tx.Rollback()
 		return err
 	}
@@ -111,9 +122,52 @@ func (r *OrderRepository) SaveOrder(ctx context.Context, order *model.Order) err
 	return nil
 }
 
+// reserveStock locks the products referenced by items and decrements their
+// stock. Rows are locked in ascending ID order so concurrent orders touching
+// the same products cannot deadlock.
+func reserveStock(tx *gorm.DB, items []model.OrderItem) error {
+	quantities, productIDs := quantitiesByProduct(items)
+
+	for _, productID := range productIDs {
+		var product model.Product
+		err := tx.Model(&model.Product{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "stock").
+			Where("id = ? AND deleted_at IS NULL", productID).
+			First(&product).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrProductNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		requested := quantities[productID]
+		if requested > product.Stock {
+			return &model.InsufficientStockError{
+				ProductID: productID,
+				Requested: requested,
+				Available: product.Stock,
+			}
+		}
+
+		err = tx.Model(&model.Product{}).
+			Where("id = ?", productID).
+			Updates(map[string]interface{}{
+				"stock":      gorm.Expr("stock - ?", requested),
+				"updated_at": time.Now(),
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (r *OrderRepository) Update(ctx context.Context, order *model.Order) error {
 	if order == nil || order.ID == "" {
-		return errors.New("invalid order: ID is required")
+		return model.ErrInvalidOrderID
 	}
 
 	logrus.WithFields(logrus.Fields{
@@ -121,19 +175,78 @@ func (r *OrderRepository) Update(ctx context.Context, order *model.Order) error
 		"status":   order.Status,
 	}).Info("[DEBUG] Updating order in database...")
 
-	err := r.db.WithContext(ctx).
-		Model(&order).
+	tx := r.db.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var current model.Order
+	err := tx.Model(&model.Order{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "status").
+		Where("id = ? AND deleted_at IS NULL", order.ID).
+		First(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		tx.Rollback()
+		return model.ErrOrderNotFound
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// The status is re-checked under the row lock so that two concurrent
+	// transitions cannot both apply, e.g. a payment racing a cancellation.
+	if current.Status != order.Status && !model.CanTransitionOrderStatus(current.Status, order.Status) {
+		tx.Rollback()
+		return &model.InvalidOrderStatusTransitionError{From: current.Status, To: order.Status}
+	}
+
+	err = tx.Model(&model.Order{}).
 		Where("id = ?", order.ID).
 		Updates(map[string]interface{}{
 			"status":     order.Status,
 			"updated_at": time.Now(),
 		}).Error
-
 	if err != nil {
+		tx.Rollback()
 		logrus.WithError(err).Error("[ERROR] Failed to update order")
 		return err
 	}
 
+	if current.Status != order.Status {
+		from := current.Status
+		if err := recordStatusChange(ctx, tx, order.ID, &from, order.Status, order.StatusReason); err != nil {
+			tx.Rollback()
+			logrus.WithError(err).Error("[ERROR] Failed to record order status history")
+			return err
+		}
+	}
+
+	if model.OrderStatusReleasesStock(current.Status, order.Status) {
+		if err := releaseStock(tx, order.ID); err != nil {
+			tx.Rollback()
+			logrus.WithError(err).Error("[ERROR] Failed to release reserved stock")
+			return err
+		}
+
+		if err := releaseCoupon(tx, order.ID); err != nil {
+			tx.Rollback()
+			logrus.WithError(err).Error("[ERROR] Failed to release coupon usage")
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
 	logrus.WithFields(logrus.Fields{
 		"order_id": order.ID,
 		"status":   order.Status,
@@ -141,6 +254,63 @@ func (r *OrderRepository) Update(ctx context.Context, order *model.Order) error
 
 	return nil
 }
+
+// recordStatusChange appends an entry to the order's status history, crediting
+// the change to the actor carried by ctx.
+func recordStatusChange(ctx context.Context, tx *gorm.DB, orderID string, from *string, to, reason string) error {
+	actorUserID, actorService := model.ActorFromContext(ctx)
+
+	return tx.Create(&model.OrderStatusHistory{
+		OrderID:      orderID,
+		FromStatus:   from,
+		ToStatus:     to,
+		ActorUserID:  actorUserID,
+		ActorService: actorService,
+		Reason:       reason,
+		CreatedAt:    time.Now(),
+	}).Error
+}
+
+// quantitiesByProduct sums item quantities per product and returns the product
+// IDs in ascending order.
+func quantitiesByProduct(items []model.OrderItem) (map[int64]int64, []int64) {
+	quantities := make(map[int64]int64)
+	var productIDs []int64
+	for _, item := range items {
+		if _, ok := quantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+	return quantities, productIDs
+}
+
+// releaseStock returns the quantities reserved by an order to its products.
+func releaseStock(tx *gorm.DB, orderID string) error {
+	var items []model.OrderItem
+	if err := tx.Where("order_id = ? AND deleted_at IS NULL", orderID).Find(&items).Error; err != nil {
+		return err
+	}
+
+	quantities, productIDs := quantitiesByProduct(items)
+
+	for _, productID := range productIDs {
+		err := tx.Model(&model.Product{}).
+			Where("id = ?", productID).
+			Updates(map[string]interface{}{
+				"stock":      gorm.Expr("stock + ?", quantities[productID]),
+				"updated_at": time.Now(),
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (r *OrderRepository) Delete(ctx context.Context, id string) error {
 	now := time.Now()
 	err := r.db.WithContext(ctx).
@@ -153,3 +323,16 @@ func (r *OrderRepository) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (r *OrderRepository) FindStatusHistory(ctx context.Context, orderID string) ([]*model.OrderStatusHistory, error) {
+	var history []*model.OrderStatusHistory
+	err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC, id ASC").
+		Find(&history).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -51,7 +51,7 @@ func (r *ProductRepo) FindById(ctx context.Context, id int64) (*model.Product, e
 		First(&product).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, errors.New("product not found")
+		return nil, model.ErrProductNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -93,7 +93,7 @@ func (r *ProductRepo) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (r *ProductRepo) GetPriceByID(ctx context.Context, productID int64, price *float64) error {
+func (r *ProductRepo) GetPriceByID(ctx context.Context, productID int64, price *model.Money) error {
 	return r.db.WithContext(ctx).Model(&model.Product{}).
 		Select("price").
 		Where("id = ?", productID).
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepo(db *gorm.DB) model.IRoleRepository {
+	return &RoleRepository{db: db}
+}
+
+func (r *RoleRepository) ChangeRole(ctx context.Context, change *model.RoleChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return changeRole(tx, change, "")
+	})
+}
+
+func (r *RoleRepository) FindRoleChanges(ctx context.Context, userID int64) ([]*model.RoleChange, error) {
+	var changes []*model.RoleChange
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC, id ASC").
+		Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *RoleRepository) CreateSellerApplication(ctx context.Context, application *model.SellerApplication) error {
+	// A user may only have one pending application at a time.
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "user_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status = 'pending'"}}},
+			DoNothing:   true,
+		}).
+		Create(application)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrSellerApplicationExists
+	}
+	return nil
+}
+
+func (r *RoleRepository) FindSellerApplications(ctx context.Context, status string) ([]*model.SellerApplication, error) {
+	var applications []*model.SellerApplication
+
+	query := r.db.WithContext(ctx).Order("created_at ASC, id ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Find(&applications).Error; err != nil {
+		return nil, err
+	}
+	return applications, nil
+}
+
+func (r *RoleRepository) FindSellerApplicationById(ctx context.Context, id int64) (*model.SellerApplication, error) {
+	var application model.SellerApplication
+	err := r.db.WithContext(ctx).First(&application, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrSellerApplicationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &application, nil
+}
+
+func (r *RoleRepository) ReviewSellerApplication(ctx context.Context, application model.SellerApplication, change *model.RoleChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.SellerApplication{}).
+			Where("id = ? AND status = ?", application.ID, model.SellerApplicationPending).
+			Updates(map[string]interface{}{
+				"status":      application.Status,
+				"reviewed_by": application.ReviewedBy,
+				"review_note": application.ReviewNote,
+				"reviewed_at": application.ReviewedAt,
+				"updated_at":  time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrSellerApplicationReviewed
+		}
+
+		if change == nil {
+			return nil
+		}
+		return changeRole(tx, change, model.RoleCustomer)
+	})
+}
+
+// changeRole locks the user, updates the role and writes the audit entry.
+// A user who already has the role is left alone. When fromRole is set, the
+// user must currently have it.
+func changeRole(tx *gorm.DB, change *model.RoleChange, fromRole string) error {
+	var user model.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "role").
+		Where("id = ? AND deleted_at IS NULL", change.UserID).
+		First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if fromRole != "" && user.Role != fromRole {
+		return model.ErrSellerApplicationNotAvailable
+	}
+
+	change.FromRole = user.Role
+	if change.FromRole == change.ToRole {
+		return nil
+	}
+	change.CreatedAt = time.Now()
+
+	err = tx.Model(&model.User{}).
+		Where("id = ?", change.UserID).
+		Updates(map[string]interface{}{
+			"role":       change.ToRole,
+			"updated_at": time.Now(),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(change).Error
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TwoFactorRepo struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepo(db *gorm.DB) model.ITwoFactorRepository {
+	return &TwoFactorRepo{db: db}
+}
+
+func (r *TwoFactorRepo) FindTOTP(ctx context.Context, userID int64) (*model.UserTOTP, error) {
+	var totp model.UserTOTP
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&totp).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &totp, nil
+}
+
+func (r *TwoFactorRepo) SaveTOTP(ctx context.Context, totp model.UserTOTP) error {
+	totp.CreatedAt = time.Now()
+
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"secret_encrypted", "last_used_step", "created_at"}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "user_totp.confirmed_at IS NULL"},
+			}},
+		}).
+		Create(&totp)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+func (r *TwoFactorRepo) ConfirmTOTP(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.UserTOTP{}).
+			Where("user_id = ? AND confirmed_at IS NULL", userID).
+			Updates(map[string]interface{}{
+				"confirmed_at":   time.Now(),
+				"last_used_step": step,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrTwoFactorNotEnrolled
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]model.RecoveryCode, 0, len(recoveryCodeHashes))
+		for _, hash := range recoveryCodeHashes {
+			codes = append(codes, model.RecoveryCode{
+				UserID:    userID,
+				CodeHash:  hash,
+				CreatedAt: time.Now(),
+			})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *TwoFactorRepo) UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.UserTOTP{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *TwoFactorRepo) DeleteTOTP(ctx context.Context, userID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.UserTOTP{}).Error
+	})
+}
+
+func (r *TwoFactorRepo) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *TwoFactorRepo) CreateLoginChallenge(ctx context.Context, challenge model.LoginChallenge) error {
+	challenge.CreatedAt = time.Now()
+	return r.db.WithContext(ctx).Create(&challenge).Error
+}
+
+func (r *TwoFactorRepo) FindLoginChallenge(ctx context.Context, tokenHash string) (*model.LoginChallenge, error) {
+	var challenge model.LoginChallenge
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ? AND expires_at > ?", tokenHash, time.Now()).
+		First(&challenge).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrLoginChallengeInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *TwoFactorRepo) FailLoginChallenge(ctx context.Context, id int64, maxAttempts int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.LoginChallenge{}).
+			Where("id = ?", id).
+			Update("attempts", gorm.Expr("attempts + 1")).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id = ? AND attempts >= ?", id, maxAttempts).
+			Delete(&model.LoginChallenge{}).Error
+	})
+}
+
+func (r *TwoFactorRepo) DeleteLoginChallenge(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.LoginChallenge{}).Error
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepo struct {
@@ -24,7 +25,8 @@ func NewUserRepo(db *gorm.DB) model.IUserRepository {
 func (u *UserRepo) FindByEmail(ctx context.Context, email string) *model.User {
 	var user model.User
 
-	err := u.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	// Deleted users can neither log in nor reset their password.
+	err := u.db.WithContext(ctx).Where("email = ? AND deleted_at IS NULL", email).First(&user).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			log.Printf("No user found with email: %s", email)
@@ -52,7 +54,7 @@ func (u *UserRepo) FindById(ctx context.Context, id int64) (*model.User, error)
 	var user model.User
 	err := u.db.WithContext(ctx).First(&user, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, errors.New("user not found")
+		return nil, model.ErrUserNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -82,10 +84,21 @@ func (r *UserRepo) FindAll(ctx context.Context, user model.User) ([]*model.User,
 func (u *UserRepo) Update(ctx context.Context, user model.User) error {
 	user.UpdatedAt = time.Now()
 
-	err := u.db.WithContext(ctx).
-		Model(&model.User{}).
-		Where("id = ? AND deleted_at IS NULL", user.ID).
-		Updates(user).Error
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// A changed email address has to be verified again.
+		if user.Email != "" {
+			err := tx.Model(&model.User{}).
+				Where("id = ? AND email <> ?", user.ID, user.Email).
+				Update("email_verified_at", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&model.User{}).
+			Where("id = ? AND deleted_at IS NULL", user.ID).
+			Updates(user).Error
+	})
 
 	if err != nil {
 		return err
@@ -120,7 +133,7 @@ func (u *UserRepo) FindSessionByToken(ctx context.Context, token string) (*model
 		First(&session).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, errors.New("session not found or expired")
+		return nil, model.ErrSessionNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -135,3 +148,247 @@ func (u *UserRepo) DeleteSession(ctx context.Context, token string) error {
 	}
 	return nil
 }
+
+func (u *UserRepo) CreateRefreshToken(ctx context.Context, token model.RefreshToken) error {
+	token.CreatedAt = time.Now()
+	return u.db.WithContext(ctx).Create(&token).Error
+}
+
+func (u *UserRepo) FindRefreshToken(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := u.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (u *UserRepo) RotateRefreshToken(ctx context.Context, tokenHash string, next model.RefreshToken, accessToken string) (string, error) {
+	reused := false
+	var session model.UserSession
+
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current model.RefreshToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).
+			First(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrRefreshTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", current.SessionID).
+			First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrRefreshTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		// A used token coming back means it leaked. Deleting the session
+		// takes every refresh token of the family with it.
+		if current.UsedAt != nil {
+			reused = true
+			return tx.Where("id = ?", current.SessionID).Delete(&model.UserSession{}).Error
+		}
+
+		if current.ExpiresAt.Before(time.Now()) {
+			return model.ErrRefreshTokenInvalid
+		}
+
+		err = tx.Model(&model.RefreshToken{}).
+			Where("id = ?", current.ID).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		next.UserID = current.UserID
+		next.SessionID = current.SessionID
+		next.CreatedAt = time.Now()
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.UserSession{}).
+			Where("id = ?", current.SessionID).
+			Updates(map[string]interface{}{
+				"token":      accessToken,
+				"expires_at": next.ExpiresAt,
+			}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if reused {
+		return session.Token, model.ErrRefreshTokenReused
+	}
+	return session.Token, nil
+}
+
+func (u *UserRepo) FindSessionsByUserID(ctx context.Context, userID int64) ([]*model.UserSession, error) {
+	var sessions []*model.UserSession
+	err := u.db.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("COALESCE(last_seen_at, created_at) DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (u *UserRepo) DeleteSessionByID(ctx context.Context, userID int64, id int64) (*model.UserSession, error) {
+	var sessions []*model.UserSession
+	err := u.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, model.ErrSessionNotFound
+	}
+	return sessions[0], nil
+}
+
+func (u *UserRepo) DeleteSessionsByUserID(ctx context.Context, userID int64, exceptID int64) ([]*model.UserSession, error) {
+	var sessions []*model.UserSession
+	err := u.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("user_id = ? AND id <> ?", userID, exceptID).
+		Delete(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (u *UserRepo) TouchSession(ctx context.Context, id int64, lastSeenAt time.Time) error {
+	return u.db.WithContext(ctx).
+		Model(&model.UserSession{}).
+		Where("id = ?", id).
+		Update("last_seen_at", lastSeenAt).Error
+}
+
+func (u *UserRepo) CreatePasswordResetToken(ctx context.Context, token model.PasswordResetToken) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Delete(&model.PasswordResetToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		token.CreatedAt = time.Now()
+		return tx.Create(&token).Error
+	})
+}
+
+func (u *UserRepo) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) ([]*model.UserSession, error) {
+	var sessions []*model.UserSession
+
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var token model.PasswordResetToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+			First(&token).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrPasswordResetTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&model.PasswordResetToken{}).
+			Where("id = ?", token.ID).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND deleted_at IS NULL", token.UserID).
+			Updates(map[string]interface{}{
+				"password":   passwordHash,
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrPasswordResetTokenInvalid
+		}
+
+		return tx.Clauses(clause.Returning{}).
+			Where("user_id = ?", token.UserID).
+			Delete(&sessions).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (u *UserRepo) CreateEmailVerificationToken(ctx context.Context, token model.EmailVerificationToken) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Delete(&model.EmailVerificationToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		token.CreatedAt = time.Now()
+		return tx.Create(&token).Error
+	})
+}
+
+func (u *UserRepo) FindLatestEmailVerificationToken(ctx context.Context, userID int64) (*model.EmailVerificationToken, error) {
+	var token model.EmailVerificationToken
+	err := u.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (u *UserRepo) VerifyEmail(ctx context.Context, tokenHash string) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var token model.EmailVerificationToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+			First(&token).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ErrEmailVerificationTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&model.EmailVerificationToken{}).
+			Where("id = ?", token.ID).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.User{}).
+			Where("id = ? AND email_verified_at IS NULL", token.UserID).
+			Update("email_verified_at", time.Now()).Error
+	})
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+type AddressUsecase struct {
+	addressRepo model.IAddressRepository
+}
+
+func NewAddressUsecase(addressRepo model.IAddressRepository) model.IAddressUsecase {
+	return &AddressUsecase{addressRepo: addressRepo}
+}
+
+func (u *AddressUsecase) FindAllByUserID(ctx context.Context, userID int64) ([]*model.UserAddress, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+	})
+
+	addresses, err := u.addressRepo.FindAllByUserID(ctx, userID)
+	if err != nil {
+		log.Error("Failed to fetch addresses: ", err)
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+func (u *AddressUsecase) FindById(ctx context.Context, userID int64, id int64) (*model.UserAddress, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	})
+
+	address, err := u.addressRepo.FindById(ctx, userID, id)
+	if err != nil {
+		log.Error("Failed to fetch address: ", err)
+		return nil, err
+	}
+
+	return address, nil
+}
+
+func (u *AddressUsecase) Create(ctx context.Context, userID int64, in model.CreateAddressInput) (*model.UserAddress, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+		"in":      in,
+	})
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, err
+	}
+
+	address := model.UserAddress{
+		UserID:        userID,
+		Label:         in.Label,
+		RecipientName: in.RecipientName,
+		Phone:         in.Phone,
+		Line1:         in.Line1,
+		Line2:         in.Line2,
+		City:          in.City,
+		Province:      in.Province,
+		PostalCode:    in.PostalCode,
+		CountryCode:   in.CountryCode,
+		IsDefault:     in.IsDefault,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	if err := u.addressRepo.Create(ctx, &address); err != nil {
+		log.Error("Failed to create address: ", err)
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+func (u *AddressUsecase) Update(ctx context.Context, userID int64, id int64, in model.UpdateAddressInput) (*model.UserAddress, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+		"in":      in,
+	})
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, err
+	}
+
+	address := model.UserAddress{
+		ID:            id,
+		UserID:        userID,
+		Label:         in.Label,
+		RecipientName: in.RecipientName,
+		Phone:         in.Phone,
+		Line1:         in.Line1,
+		Line2:         in.Line2,
+		City:          in.City,
+		Province:      in.Province,
+		PostalCode:    in.PostalCode,
+		CountryCode:   in.CountryCode,
+		IsDefault:     in.IsDefault,
+	}
+
+	if err := u.addressRepo.Update(ctx, address); err != nil {
+		log.Error("Failed to update address: ", err)
+		return nil, err
+	}
+
+	return u.addressRepo.FindById(ctx, userID, id)
+}
+
+func (u *AddressUsecase) Delete(ctx context.Context, userID int64, id int64) error {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	})
+
+	if err := u.addressRepo.Delete(ctx, userID, id); err != nil {
+		log.Error("Failed to delete address: ", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+// apiKeyTouchInterval limits how often last_used_at is written for a busy
+// key.
+const apiKeyTouchInterval = time.Minute
+
+// apiKeyPrefixLength is how much of the key, after APIKeyPrefix, is kept in
+// the clear to tell keys apart.
+const apiKeyPrefixLength = 8
+
+type APIKeyUsecase struct {
+	apiKeyRepo model.IAPIKeyRepository
+	userRepo   model.IUserRepository
+}
+
+func NewAPIKeyUsecase(apiKeyRepo model.IAPIKeyRepository, userRepo model.IUserRepository) model.IAPIKeyUsecase {
+	return &APIKeyUsecase{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (u *APIKeyUsecase) FindAll(ctx context.Context, ownerID int64) ([]*model.APIKey, error) {
+	keys, err := u.apiKeyRepo.FindAll(ctx, ownerID)
+	if err != nil {
+		logrus.Error("Failed to fetch api keys: ", err)
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (u *APIKeyUsecase) FindById(ctx context.Context, id int64) (*model.APIKey, error) {
+	key, err := u.apiKeyRepo.FindById(ctx, id)
+	if err != nil {
+		logrus.WithField("id", id).Error("Failed to fetch api key: ", err)
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (u *APIKeyUsecase) Create(ctx context.Context, in model.CreateAPIKeyInput) (*model.APIKey, string, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"owner_id": in.OwnerID,
+		"name":     in.Name,
+	})
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, "", err
+	}
+
+	owner, err := u.userRepo.FindById(ctx, in.OwnerID)
+	if err != nil {
+		log.Error("Failed to fetch api key owner: ", err)
+		return nil, "", err
+	}
+
+	scopes, err := parseScopes(owner.Role, in.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		log.Error(err)
+		return nil, "", err
+	}
+	plaintext := model.APIKeyPrefix + secret
+
+	key := model.APIKey{
+		OwnerID:   in.OwnerID,
+		Name:      in.Name,
+		Prefix:    plaintext[:len(model.APIKeyPrefix)+apiKeyPrefixLength],
+		KeyHash:   helper.HashToken(plaintext),
+		Scopes:    scopes,
+		ExpiresAt: in.ExpiresAt,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := u.apiKeyRepo.Create(ctx, &key); err != nil {
+		log.Error("Failed to create api key: ", err)
+		return nil, "", err
+	}
+
+	log.Info("API key created: ", key.Prefix)
+	return &key, plaintext, nil
+}
+
+func (u *APIKeyUsecase) Update(ctx context.Context, id int64, in model.UpdateAPIKeyInput) (*model.APIKey, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"id": id,
+	})
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, err
+	}
+
+	key, err := u.apiKeyRepo.FindById(ctx, id)
+	if err != nil {
+		log.Error("Failed to fetch api key for update: ", err)
+		return nil, err
+	}
+
+	owner, err := u.userRepo.FindById(ctx, key.OwnerID)
+	if err != nil {
+		log.Error("Failed to fetch api key owner: ", err)
+		return nil, err
+	}
+
+	scopes, err := parseScopes(owner.Role, in.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Name = in.Name
+	key.Scopes = scopes
+	key.ExpiresAt = in.ExpiresAt
+
+	if err := u.apiKeyRepo.Update(ctx, *key); err != nil {
+		log.Error("Failed to update api key: ", err)
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (u *APIKeyUsecase) Delete(ctx context.Context, id int64) error {
+	if err := u.apiKeyRepo.Delete(ctx, id); err != nil {
+		logrus.WithField("id", id).Error("Failed to delete api key: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (u *APIKeyUsecase) Authenticate(ctx context.Context, plaintext string) (model.CustomClaims, error) {
+	key, err := u.apiKeyRepo.FindByHash(ctx, helper.HashToken(plaintext))
+	if err != nil {
+		return model.CustomClaims{}, err
+	}
+
+	now := time.Now()
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+		return model.CustomClaims{}, model.ErrAPIKeyInvalid
+	}
+
+	// The owner's current role applies, so demoting or deleting the owner
+	// takes effect on their keys too.
+	owner, err := u.userRepo.FindById(ctx, key.OwnerID)
+	if err != nil || owner.DeletedAt != nil {
+		return model.CustomClaims{}, model.ErrAPIKeyInvalid
+	}
+
+	if err := u.apiKeyRepo.TouchLastUsed(ctx, key.ID, now, apiKeyTouchInterval); err != nil {
+		logrus.WithField("id", key.ID).Warn("Failed to update api key last used: ", err)
+	}
+
+	return model.CustomClaims{
+		UserID:   owner.ID,
+		Role:     owner.Role,
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+// parseScopes checks that every scope is a permission that keys may hold
+// and that role, the owner's role, grants.
+func parseScopes(role string, scopes []string) ([]model.Permission, error) {
+	permissions := make([]model.Permission, 0, len(scopes))
+	for _, scope := range scopes {
+		permission := model.Permission(scope)
+		if !model.IsGrantableScope(permission) || !model.HasPermission(role, permission) {
+			return nil, fmt.Errorf("%w: %s", model.ErrInvalidScope, scope)
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, nil
+}
@@ -0,0 +1,259 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+type CartUsecase struct {
+	cartRepo     model.ICartRepository
+	productRepo  model.IProductRepository
+	orderUsecase model.IOrderUsecase
+}
+
+func NewCartUsecase(
+	cartRepo model.ICartRepository,
+	productRepo model.IProductRepository,
+	orderUsecase model.IOrderUsecase,
+) model.ICartUsecase {
+	return &CartUsecase{
+		cartRepo:     cartRepo,
+		productRepo:  productRepo,
+		orderUsecase: orderUsecase,
+	}
+}
+
+func (u *CartUsecase) FindByUserID(ctx context.Context, userID int64) (*model.Cart, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+	})
+
+	cart, err := u.cartRepo.FindOrCreate(ctx, userID)
+	if err != nil {
+		log.Error("Failed to fetch cart: ", err)
+		return nil, err
+	}
+
+	if err := u.revalidate(ctx, cart); err != nil {
+		log.Error("Failed to revalidate cart: ", err)
+		return nil, err
+	}
+
+	return cart, nil
+}
+
+func (u *CartUsecase) AddItem(ctx context.Context, userID int64, in model.AddCartItemInput) (*model.Cart, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+		"in":      in,
+	})
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, err
+	}
+
+	product, err := u.productRepo.FindById(ctx, in.ProductID)
+	if err != nil {
+		log.Error("Failed to fetch product: ", err)
+		return nil, err
+	}
+
+	cart, err := u.cartRepo.FindOrCreate(ctx, userID)
+	if err != nil {
+		log.Error("Failed to fetch cart: ", err)
+		return nil, err
+	}
+
+	quantity := in.Quantity
+	for _, item := range cart.CartItems {
+		if item.ProductID == in.ProductID {
+			quantity += item.Quantity
+		}
+	}
+	if quantity > product.Stock {
+		return nil, &model.InsufficientStockError{
+			ProductID: product.ID,
+			Requested: quantity,
+			Available: product.Stock,
+		}
+	}
+
+	err = u.cartRepo.AddItem(ctx, model.CartItem{
+		CartID:    cart.ID,
+		ProductID: product.ID,
+		Quantity:  in.Quantity,
+		Price:     product.Price,
+	})
+	if err != nil {
+		log.Error("Failed to add cart item: ", err)
+		return nil, err
+	}
+
+	return u.FindByUserID(ctx, userID)
+}
+
+func (u *CartUsecase) UpdateItem(ctx context.Context, userID int64, productID int64, in model.UpdateCartItemInput) (*model.Cart, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"product_id": productID,
+		"in":         in,
+	})
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, err
+	}
+
+	product, err := u.productRepo.FindById(ctx, productID)
+	if err != nil {
+		log.Error("Failed to fetch product: ", err)
+		return nil, err
+	}
+
+	if in.Quantity > product.Stock {
+		return nil, &model.InsufficientStockError{
+			ProductID: product.ID,
+			Requested: in.Quantity,
+			Available: product.Stock,
+		}
+	}
+
+	cart, err := u.cartRepo.FindOrCreate(ctx, userID)
+	if err != nil {
+		log.Error("Failed to fetch cart: ", err)
+		return nil, err
+	}
+
+	err = u.cartRepo.UpdateItem(ctx, model.CartItem{
+		CartID:    cart.ID,
+		ProductID: productID,
+		Quantity:  in.Quantity,
+		Price:     product.Price,
+	})
+	if err != nil {
+		log.Error("Failed to update cart item: ", err)
+		return nil, err
+	}
+
+	return u.FindByUserID(ctx, userID)
+}
+
+func (u *CartUsecase) RemoveItem(ctx context.Context, userID int64, productID int64) (*model.Cart, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"product_id": productID,
+	})
+
+	cart, err := u.cartRepo.FindOrCreate(ctx, userID)
+	if err != nil {
+		log.Error("Failed to fetch cart: ", err)
+		return nil, err
+	}
+
+	if err := u.cartRepo.DeleteItem(ctx, cart.ID, productID); err != nil {
+		log.Error("Failed to remove cart item: ", err)
+		return nil, err
+	}
+
+	return u.FindByUserID(ctx, userID)
+}
+
+func (u *CartUsecase) Checkout(ctx context.Context, userID int64, in model.CheckoutCartInput) (*model.Order, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+	})
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, err
+	}
+
+	cart, err := u.cartRepo.FindOrCreate(ctx, userID)
+	if err != nil {
+		log.Error("Failed to fetch cart: ", err)
+		return nil, err
+	}
+
+	if len(cart.CartItems) == 0 {
+		return nil, model.ErrCartEmpty
+	}
+
+	// Without a client supplied key, the cart version doubles as one so
+	// that concurrent checkouts of the same cart place a single order.
+	idempotencyKey := in.IdempotencyKey
+	if idempotencyKey == "" {
+		idempotencyKey = fmt.Sprintf("cart-%d-%d", cart.ID, cart.UpdatedAt.UnixMicro())
+	}
+
+	orderInput := model.CreateOrderInput{
+		UserID:            userID,
+		CouponCode:        in.CouponCode,
+		ShippingAddressID: in.ShippingAddressID,
+		BillingAddressID:  in.BillingAddressID,
+		IdempotencyKey:    idempotencyKey,
+	}
+	for _, item := range cart.CartItems {
+		orderInput.OrderItems = append(orderInput.OrderItems, model.CreateOrderItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	order, err := u.orderUsecase.Create(ctx, orderInput)
+	if err != nil {
+		log.Error("Failed to create order from cart: ", err)
+		return nil, err
+	}
+
+	// A retry after a failure here replays the same order through the
+	// idempotency key, as the cart version has not moved, and tries again.
+	if err := u.cartRepo.RemoveCheckedOut(ctx, *cart); err != nil {
+		log.Error("Failed to remove checked out items from cart: ", err)
+		return nil, err
+	}
+
+	log.Info("Cart checked out into order: ", order.ID)
+	return order, nil
+}
+
+// revalidate refreshes every cart item against the current product price and
+// stock, and recomputes the cart subtotal from current prices.
+func (u *CartUsecase) revalidate(ctx context.Context, cart *model.Cart) error {
+	cart.Subtotal = 0
+
+	for i := range cart.CartItems {
+		item := &cart.CartItems[i]
+
+		product, err := u.productRepo.FindById(ctx, item.ProductID)
+		if errors.Is(err, model.ErrProductNotFound) {
+			item.Available = false
+			item.Message = "product is no longer available"
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		item.ProductName = product.Name
+		item.CurrentPrice = product.Price
+		item.PriceChanged = product.Price != item.Price
+		item.AvailableStock = product.Stock
+		item.Available = item.Quantity <= product.Stock
+		if !item.Available {
+			item.Message = "insufficient stock"
+		}
+
+		if cart.Currency == "" {
+			cart.Currency = product.Currency
+		}
+		cart.Subtotal += product.Price.Mul(item.Quantity)
+	}
+
+	return nil
+}
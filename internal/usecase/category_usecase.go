@@ -2,7 +2,6 @@ package usecase
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -109,14 +108,9 @@ func (u *CategoryUsecase) Delete(ctx context.Context, id int64) error {
 		return err
 	}
 
-	if category == nil {
-		log.Error("Category not found")
-		return errors.New("category not found")
-	}
-
 	if category.DeletedAt != nil {
 		log.Error("Category already deleted")
-		return errors.New("category already deleted")
+		return model.ErrCategoryNotFound
 	}
 
 	if err := u.categoryRepo.Delete(ctx, id); err != nil {
@@ -0,0 +1,245 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+var errCouponWindow = model.NewError(model.KindValidation, "coupon_window_invalid", "coupon ends_at must be after starts_at")
+
+type CouponUsecase struct {
+	couponRepo model.ICouponRepository
+}
+
+func NewCouponUsecase(couponRepo model.ICouponRepository) model.ICouponUsecase {
+	return &CouponUsecase{couponRepo: couponRepo}
+}
+
+func (u *CouponUsecase) FindAll(ctx context.Context) ([]*model.Coupon, error) {
+	coupons, err := u.couponRepo.FindAll(ctx)
+	if err != nil {
+		logrus.Error("Failed to fetch coupons: ", err)
+		return nil, err
+	}
+
+	return coupons, nil
+}
+
+func (u *CouponUsecase) FindById(ctx context.Context, id int64) (*model.Coupon, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"id": id,
+	})
+
+	coupon, err := u.couponRepo.FindById(ctx, id)
+	if err != nil {
+		log.Error("Failed to fetch coupon by ID: ", err)
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (u *CouponUsecase) Create(ctx context.Context, in model.CreateCouponInput) (*model.Coupon, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"in": in,
+	})
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, err
+	}
+
+	if in.StartsAt != nil && in.EndsAt != nil && !in.EndsAt.After(*in.StartsAt) {
+		return nil, errCouponWindow
+	}
+
+	coupon := model.Coupon{
+		Code:           in.Code,
+		Type:           in.Type,
+		PercentOff:     in.PercentOff,
+		AmountOff:      in.AmountOff,
+		Currency:       in.Currency,
+		MaxDiscount:    in.MaxDiscount,
+		MinOrderAmount: in.MinOrderAmount,
+		UsageLimit:     in.UsageLimit,
+		PerUserLimit:   in.PerUserLimit,
+		StartsAt:       in.StartsAt,
+		EndsAt:         in.EndsAt,
+		ProductIDs:     in.ProductIDs,
+		CategoryIDs:    in.CategoryIDs,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if err := u.couponRepo.Create(ctx, &coupon); err != nil {
+		log.Error("Failed to create coupon: ", err)
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+func (u *CouponUsecase) Update(ctx context.Context, id int64, in model.UpdateCouponInput) (*model.Coupon, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"id": id,
+		"in": in,
+	})
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, err
+	}
+
+	if in.StartsAt != nil && in.EndsAt != nil && !in.EndsAt.After(*in.StartsAt) {
+		return nil, errCouponWindow
+	}
+
+	coupon, err := u.couponRepo.FindById(ctx, id)
+	if err != nil {
+		log.Error("Failed to fetch coupon for update: ", err)
+		return nil, err
+	}
+
+	coupon.Code = in.Code
+	coupon.Type = in.Type
+	coupon.PercentOff = in.PercentOff
+	coupon.AmountOff = in.AmountOff
+	coupon.Currency = in.Currency
+	coupon.MaxDiscount = in.MaxDiscount
+	coupon.MinOrderAmount = in.MinOrderAmount
+	coupon.UsageLimit = in.UsageLimit
+	coupon.PerUserLimit = in.PerUserLimit
+	coupon.StartsAt = in.StartsAt
+	coupon.EndsAt = in.EndsAt
+	coupon.ProductIDs = in.ProductIDs
+	coupon.CategoryIDs = in.CategoryIDs
+
+	if err := u.couponRepo.Update(ctx, *coupon); err != nil {
+		log.Error("Failed to update coupon: ", err)
+		return nil, err
+	}
+
+	return u.couponRepo.FindById(ctx, id)
+}
+
+func (u *CouponUsecase) Delete(ctx context.Context, id int64) error {
+	log := logrus.WithFields(logrus.Fields{
+		"id": id,
+	})
+
+	if _, err := u.couponRepo.FindById(ctx, id); err != nil {
+		log.Error("Failed to fetch coupon for deletion: ", err)
+		return err
+	}
+
+	if err := u.couponRepo.Delete(ctx, id); err != nil {
+		log.Error("Failed to delete coupon: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// applyCoupon computes the coupon discount for the order and spreads it over
+// the eligible items in proportion to their line totals, so that the item
+// discounts always add up to the order discount. order.Subtotal must already
+// be set.
+func applyCoupon(coupon *model.Coupon, order *model.Order, now time.Time) error {
+	notApplicable := func(reason string) error {
+		return &model.CouponNotApplicableError{Code: coupon.Code, Reason: reason}
+	}
+
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return notApplicable("coupon is not active yet")
+	}
+	if coupon.EndsAt != nil && !now.Before(*coupon.EndsAt) {
+		return notApplicable("coupon has expired")
+	}
+	if coupon.Currency != "" && coupon.Currency != order.Currency {
+		return notApplicable("coupon currency does not match the order")
+	}
+
+	if order.Subtotal < coupon.MinOrderAmount {
+		return notApplicable("order total is below the minimum of " + coupon.MinOrderAmount.String())
+	}
+
+	eligible := make([]int, 0, len(order.OrderItems))
+	var eligibleTotal model.Money
+	for i, item := range order.OrderItems {
+		if couponCovers(coupon, item.ProductID, item.CategoryID) {
+			eligible = append(eligible, i)
+			eligibleTotal += item.Price.Mul(item.Quantity)
+		}
+	}
+	if eligibleTotal == 0 {
+		return notApplicable("no items in the order are eligible")
+	}
+
+	var discount model.Money
+	switch coupon.Type {
+	case model.CouponTypePercentage:
+		discount = eligibleTotal * model.Money(coupon.PercentOff) / 100
+		if coupon.MaxDiscount > 0 && discount > coupon.MaxDiscount {
+			discount = coupon.MaxDiscount
+		}
+	case model.CouponTypeFixed:
+		discount = coupon.AmountOff
+	}
+	if discount > eligibleTotal {
+		discount = eligibleTotal
+	}
+	if discount <= 0 {
+		return notApplicable("coupon gives no discount on this order")
+	}
+
+	var allocated model.Money
+	for _, i := range eligible {
+		item := &order.OrderItems[i]
+		item.DiscountAmount = discount * item.Price.Mul(item.Quantity) / eligibleTotal
+		allocated += item.DiscountAmount
+	}
+
+	// Hand out the rounding remainder one minor unit at a time.
+	for remainder := discount - allocated; remainder > 0; {
+		for _, i := range eligible {
+			item := &order.OrderItems[i]
+			if remainder == 0 {
+				break
+			}
+			if item.DiscountAmount < item.Price.Mul(item.Quantity) {
+				item.DiscountAmount++
+				remainder--
+			}
+		}
+	}
+
+	code := coupon.Code
+	order.CouponID = &coupon.ID
+	order.CouponCode = &code
+	order.DiscountAmount = discount
+
+	return nil
+}
+
+func couponCovers(coupon *model.Coupon, productID int64, categoryID int64) bool {
+	if len(coupon.ProductIDs) == 0 && len(coupon.CategoryIDs) == 0 {
+		return true
+	}
+
+	for _, id := range coupon.ProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+	for _, id := range coupon.CategoryIDs {
+		if id == categoryID {
+			return true
+		}
+	}
+
+	return false
+}
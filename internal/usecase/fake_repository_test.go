@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+// fakeUserRepo keeps users in memory. Methods the tests do not use are
+// left to the embedded nil interface.
+type fakeUserRepo struct {
+	model.IUserRepository
+	users    map[int64]*model.User
+	sessions int64
+}
+
+func newFakeUserRepo(users ...*model.User) *fakeUserRepo {
+	repo := &fakeUserRepo{users: make(map[int64]*model.User)}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepo) FindById(_ context.Context, id int64) (*model.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, model.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepo) FindByEmail(_ context.Context, email string) *model.User {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) && user.DeletedAt == nil {
+			copied := *user
+			return &copied
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) CreateSession(_ context.Context, session model.UserSession) (*model.UserSession, error) {
+	r.sessions++
+	session.ID = r.sessions
+	return &session, nil
+}
+
+func (r *fakeUserRepo) CreateRefreshToken(_ context.Context, _ model.RefreshToken) error {
+	return nil
+}
+
+// fakeLoginAttemptRepo follows the rules of the upsert in
+// LoginAttemptRepo.TryAttempt: an attempt is refused while the key is
+// locked or backing off, failures outside the window are forgotten.
+type fakeLoginAttemptRepo struct {
+	mu       sync.Mutex
+	attempts map[model.LoginAttemptKey]*model.LoginAttempt
+}
+
+func newFakeLoginAttemptRepo() *fakeLoginAttemptRepo {
+	return &fakeLoginAttemptRepo{attempts: make(map[model.LoginAttemptKey]*model.LoginAttempt)}
+}
+
+func (r *fakeLoginAttemptRepo) get(key model.LoginAttemptKey) (model.LoginAttempt, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return model.LoginAttempt{}, false
+	}
+	return *attempt, true
+}
+
+func (r *fakeLoginAttemptRepo) FindByKeys(_ context.Context, keys []model.LoginAttemptKey) ([]*model.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var attempts []*model.LoginAttempt
+	for _, key := range keys {
+		if attempt, ok := r.attempts[key]; ok {
+			copied := *attempt
+			attempts = append(attempts, &copied)
+		}
+	}
+	return attempts, nil
+}
+
+func (r *fakeLoginAttemptRepo) TryAttempt(_ context.Context, key model.LoginAttemptKey, policy model.LoginThrottlePolicy) (*model.LoginAttempt, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	attempt, ok := r.attempts[key]
+	if !ok {
+		attempt = &model.LoginAttempt{Scope: key.Scope, Key: key.Key}
+		r.attempts[key] = attempt
+	} else {
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			return nil, false, nil
+		}
+		stale := attempt.LastFailureAt.Before(now.Add(-policy.FailureWindow))
+		if !stale && policy.RetryAt(*attempt).After(now) {
+			return nil, false, nil
+		}
+		if stale {
+			attempt.Failures = 0
+		}
+	}
+
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	copied := *attempt
+	return &copied, true, nil
+}
+
+func (r *fakeLoginAttemptRepo) ReleaseAttempt(_ context.Context, key model.LoginAttemptKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt, ok := r.attempts[key]; ok && attempt.Failures > 0 {
+		attempt.Failures--
+	}
+	return nil
+}
+
+func (r *fakeLoginAttemptRepo) Lock(_ context.Context, key model.LoginAttemptKey, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt, ok := r.attempts[key]; ok {
+		attempt.LockedUntil = &until
+	}
+	return nil
+}
+
+func (r *fakeLoginAttemptRepo) Reset(_ context.Context, key model.LoginAttemptKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
+
+// testLoginPolicy allows three free failures, then backs off for at least
+// a minute, and locks accounts after ten failures.
+var testLoginPolicy = model.LoginThrottlePolicy{
+	FreeAttempts:            3,
+	BackoffBase:             time.Minute,
+	BackoffMax:              time.Hour,
+	FailureWindow:           time.Hour,
+	AccountLockoutThreshold: 10,
+	IPLockoutThreshold:      50,
+	LockoutDuration:         30 * time.Minute,
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+// loginThrottle slows down password guessing by tracking failed logins per
+// email address and per client IP.
+type loginThrottle struct {
+	repo   model.ILoginAttemptRepository
+	policy model.LoginThrottlePolicy
+}
+
+func newLoginThrottle(repo model.ILoginAttemptRepository, policy model.LoginThrottlePolicy) *loginThrottle {
+	return &loginThrottle{
+		repo:   repo,
+		policy: policy,
+	}
+}
+
+// loginAttemptKeys returns the counters a login for email from ip updates.
+// Emails are tracked whether or not they belong to a user, so lockouts do
+// not reveal which accounts exist.
+func loginAttemptKeys(email string, ip string) []model.LoginAttemptKey {
+	keys := []model.LoginAttemptKey{accountAttemptKey(email)}
+	if ip != "" {
+		keys = append(keys, model.LoginAttemptKey{Scope: model.LoginAttemptScopeIP, Key: ip})
+	}
+	return keys
+}
+
+func accountAttemptKey(email string) model.LoginAttemptKey {
+	return model.LoginAttemptKey{
+		Scope: model.LoginAttemptScopeAccount,
+		Key:   strings.ToLower(strings.TrimSpace(email)),
+	}
+}
+
+// attempt counts a login against keys before the credentials are checked,
+// so concurrent guesses cannot all slip through before the first failure is
+// recorded. It returns a *model.LoginThrottledError, counting nothing, while
+// any of keys is backing off or locked. The attempts it returns are then
+// either failed with recordFailure or taken back with release.
+func (t *loginThrottle) attempt(ctx context.Context, keys []model.LoginAttemptKey) ([]*model.LoginAttempt, error) {
+	attempts := make([]*model.LoginAttempt, 0, len(keys))
+	for _, key := range keys {
+		attempt, ok, err := t.repo.TryAttempt(ctx, key, t.policy)
+		if err == nil && !ok {
+			err = t.throttled(ctx, key)
+		}
+		if err != nil {
+			t.release(ctx, attempts)
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, nil
+}
+
+// throttled tells how long key is held back for.
+func (t *loginThrottle) throttled(ctx context.Context, key model.LoginAttemptKey) error {
+	attempts, err := t.repo.FindByKeys(ctx, []model.LoginAttemptKey{key})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	// The row may have changed since it was refused; ask for a retry soon.
+	throttled := &model.LoginThrottledError{RetryAfter: time.Second}
+	for _, attempt := range attempts {
+		if retryAt := t.policy.RetryAt(*attempt); retryAt.After(now) {
+			throttled.RetryAfter = retryAt.Sub(now)
+		}
+		throttled.Locked = attempt.Scope == model.LoginAttemptScopeAccount &&
+			attempt.LockedUntil != nil && attempt.LockedUntil.After(now)
+	}
+	return throttled
+}
+
+// recordFailure keeps attempts counted as failures and locks the keys that
+// reached their lockout threshold.
+func (t *loginThrottle) recordFailure(ctx context.Context, attempts []*model.LoginAttempt) {
+	for _, attempt := range attempts {
+		threshold := t.policy.LockoutThreshold(attempt.Scope)
+		if threshold <= 0 || attempt.Failures < threshold {
+			continue
+		}
+
+		log := logrus.WithFields(logrus.Fields{
+			"scope": attempt.Scope,
+			"key":   attempt.Key,
+		})
+
+		key := model.LoginAttemptKey{Scope: attempt.Scope, Key: attempt.Key}
+		until := time.Now().Add(t.policy.LockoutDuration)
+		if err := t.repo.Lock(ctx, key, until); err != nil {
+			log.Error("Failed to lock login: ", err)
+			continue
+		}
+		log.Warnf("Login locked after %d failed attempts until %s", attempt.Failures, until.Format(time.RFC3339))
+	}
+}
+
+// release takes back attempts that did not fail.
+func (t *loginThrottle) release(ctx context.Context, attempts []*model.LoginAttempt) {
+	for _, attempt := range attempts {
+		key := model.LoginAttemptKey{Scope: attempt.Scope, Key: attempt.Key}
+		if err := t.repo.ReleaseAttempt(ctx, key); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"scope": attempt.Scope,
+				"key":   attempt.Key,
+			}).Error("Failed to release login attempt: ", err)
+		}
+	}
+}
+
+func (t *loginThrottle) reset(ctx context.Context, key model.LoginAttemptKey) error {
+	return t.repo.Reset(ctx, key)
+}
+
+var (
+	dummyPasswordHashOnce sync.Once
+	dummyPasswordHash     string
+)
+
+// checkDummyPassword spends as long as checking a real password, so logins
+// for unknown emails cannot be told apart by response time.
+func checkDummyPassword(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		hash, err := helper.HashRequestPassword("dummy password for unknown accounts")
+		if err != nil {
+			logrus.Error("Failed to hash dummy password: ", err)
+		}
+		dummyPasswordHash = hash
+	})
+
+	helper.CheckPasswordHash(password, dummyPasswordHash)
+}
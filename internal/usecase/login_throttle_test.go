@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "correct horse battery staple"
+
+// newLoginTestUsecase returns a usecase with two users without 2FA,
+// alice@example.com and bob@example.com, both with testPassword.
+func newLoginTestUsecase(t *testing.T, policy model.LoginThrottlePolicy) (*UserUsecase, *fakeLoginAttemptRepo) {
+	t.Helper()
+
+	// Sign access tokens with an ephemeral key instead of configured ones.
+	viper.Set("env", "development")
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := newFakeLoginAttemptRepo()
+	u := &UserUsecase{
+		userRepo: newFakeUserRepo(
+			&model.User{ID: 1, Email: "alice@example.com", Password: string(hash), Role: model.RoleCustomer},
+			&model.User{ID: 2, Email: "bob@example.com", Password: string(hash), Role: model.RoleCustomer},
+		),
+		loginThrottle:   newLoginThrottle(attempts, policy),
+		twoFactorRepo:   &fakeTwoFactorRepo{},
+		refreshTokenTTL: time.Hour,
+	}
+	return u, attempts
+}
+
+func login(u *UserUsecase, email, password, ip string) error {
+	_, err := u.Login(context.Background(), model.LoginInput{
+		Email:     email,
+		Password:  password,
+		IPAddress: ip,
+	})
+	return err
+}
+
+// lockoutPolicy has no backoff, so only the lockout holds logins back.
+var lockoutPolicy = model.LoginThrottlePolicy{
+	FreeAttempts:            100,
+	BackoffBase:             time.Minute,
+	BackoffMax:              time.Hour,
+	FailureWindow:           time.Hour,
+	AccountLockoutThreshold: 5,
+	IPLockoutThreshold:      8,
+	LockoutDuration:         30 * time.Minute,
+}
+
+func TestLoginLocksAccountAfterThreshold(t *testing.T) {
+	u, attempts := newLoginTestUsecase(t, lockoutPolicy)
+
+	for i := 0; i < int(lockoutPolicy.AccountLockoutThreshold); i++ {
+		// A new IP for every guess, so only the account counter adds up.
+		ip := "198.51.100." + string(rune('1'+i))
+		if err := login(u, "alice@example.com", "wrong", ip); !errors.Is(err, model.ErrInvalidCredentials) {
+			t.Fatalf("guess %d: got %v, want %v", i+1, err, model.ErrInvalidCredentials)
+		}
+	}
+
+	err := login(u, "alice@example.com", testPassword, "203.0.113.1")
+	var throttled *model.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("right password after lockout: got %v, want %T", err, throttled)
+	}
+	if !throttled.Locked {
+		t.Error("LoginThrottledError.Locked = false, want true")
+	}
+	if throttled.RetryAfter <= lockoutPolicy.LockoutDuration-time.Minute || throttled.RetryAfter > lockoutPolicy.LockoutDuration {
+		t.Errorf("RetryAfter = %s, want about %s", throttled.RetryAfter, lockoutPolicy.LockoutDuration)
+	}
+
+	// Refused attempts are not counted.
+	attempt, _ := attempts.get(accountAttemptKey("alice@example.com"))
+	if attempt.Failures != lockoutPolicy.AccountLockoutThreshold {
+		t.Errorf("account failures = %d, want %d", attempt.Failures, lockoutPolicy.AccountLockoutThreshold)
+	}
+
+	// The lockout is per account.
+	if err := login(u, "bob@example.com", testPassword, "203.0.113.1"); err != nil {
+		t.Errorf("other account: %v", err)
+	}
+}
+
+func TestLoginLocksIPAfterThreshold(t *testing.T) {
+	u, _ := newLoginTestUsecase(t, lockoutPolicy)
+	const ip = "198.51.100.7"
+
+	// Spread over accounts so no account reaches its own threshold.
+	emails := []string{"alice@example.com", "bob@example.com"}
+	for i := 0; i < int(lockoutPolicy.IPLockoutThreshold); i++ {
+		email := emails[i%len(emails)]
+		if err := login(u, email, "wrong", ip); !errors.Is(err, model.ErrInvalidCredentials) {
+			t.Fatalf("guess %d: got %v, want %v", i+1, err, model.ErrInvalidCredentials)
+		}
+	}
+
+	err := login(u, "alice@example.com", testPassword, ip)
+	var throttled *model.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("login from locked IP: got %v, want %T", err, throttled)
+	}
+	// Only account lockouts are reported as such.
+	if throttled.Locked {
+		t.Error("LoginThrottledError.Locked = true for an IP lockout, want false")
+	}
+
+	if err := login(u, "alice@example.com", testPassword, "203.0.113.1"); err != nil {
+		t.Errorf("login from another IP: %v", err)
+	}
+}
+
+func TestLoginBacksOffAfterFreeAttempts(t *testing.T) {
+	u, _ := newLoginTestUsecase(t, testLoginPolicy)
+	const ip = "198.51.100.7"
+
+	for i := 0; i < int(testLoginPolicy.FreeAttempts)+1; i++ {
+		if err := login(u, "alice@example.com", "wrong", ip); !errors.Is(err, model.ErrInvalidCredentials) {
+			t.Fatalf("guess %d: got %v, want %v", i+1, err, model.ErrInvalidCredentials)
+		}
+	}
+
+	err := login(u, "alice@example.com", testPassword, ip)
+	var throttled *model.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("login during backoff: got %v, want %T", err, throttled)
+	}
+	if throttled.Locked {
+		t.Error("LoginThrottledError.Locked = true during backoff, want false")
+	}
+	if throttled.RetryAfter <= 0 || throttled.RetryAfter > testLoginPolicy.BackoffBase {
+		t.Errorf("RetryAfter = %s, want at most %s", throttled.RetryAfter, testLoginPolicy.BackoffBase)
+	}
+}
+
+func TestLoginSuccessResetsOnlyOwnAccount(t *testing.T) {
+	u, attempts := newLoginTestUsecase(t, lockoutPolicy)
+	const ip = "198.51.100.7"
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := login(u, "alice@example.com", "wrong", ip); !errors.Is(err, model.ErrInvalidCredentials) {
+			t.Fatalf("guess %d: got %v", i+1, err)
+		}
+	}
+	if err := login(u, "bob@example.com", "wrong", ip); !errors.Is(err, model.ErrInvalidCredentials) {
+		t.Fatalf("bob's wrong password: got %v", err)
+	}
+
+	// Bob logging in from the same IP must not wipe what it did to Alice.
+	if err := login(u, "bob@example.com", testPassword, ip); err != nil {
+		t.Fatalf("bob's right password: %v", err)
+	}
+
+	tests := []struct {
+		key  model.LoginAttemptKey
+		want int64
+	}{
+		{accountAttemptKey("alice@example.com"), 3},
+		{accountAttemptKey("bob@example.com"), 0},
+		{model.LoginAttemptKey{Scope: model.LoginAttemptScopeIP, Key: ip}, 4},
+	}
+	for _, tt := range tests {
+		found, err := attempts.FindByKeys(ctx, []model.LoginAttemptKey{tt.key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got int64
+		if len(found) > 0 {
+			got = found[0].Failures
+		}
+		if got != tt.want {
+			t.Errorf("%s %s failures = %d, want %d", tt.key.Scope, tt.key.Key, got, tt.want)
+		}
+	}
+}
+
+func TestLoginConcurrentGuessesAreCounted(t *testing.T) {
+	u, attempts := newLoginTestUsecase(t, testLoginPolicy)
+	const guesses = 20
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		verified int
+	)
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := login(u, "alice@example.com", "wrong", "")
+			if errors.Is(err, model.ErrInvalidCredentials) {
+				mu.Lock()
+				verified++
+				mu.Unlock()
+			} else if !errors.Is(err, model.ErrLoginThrottled) {
+				t.Errorf("got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each attempt is counted before the password is checked, so no more
+	// than the free attempts plus the one that starts the backoff get in.
+	if want := int(testLoginPolicy.FreeAttempts) + 1; verified != want {
+		t.Errorf("%d of %d concurrent guesses were checked, want %d", verified, guesses, want)
+	}
+	attempt, _ := attempts.get(accountAttemptKey("alice@example.com"))
+	if attempt.Failures != testLoginPolicy.FreeAttempts+1 {
+		t.Errorf("account failures = %d, want %d", attempt.Failures, testLoginPolicy.FreeAttempts+1)
+	}
+}
+
+func TestLoginThrottleAttemptReleasesOnRefusal(t *testing.T) {
+	repo := newFakeLoginAttemptRepo()
+	throttle := newLoginThrottle(repo, lockoutPolicy)
+	ctx := context.Background()
+
+	account := accountAttemptKey("alice@example.com")
+	ip := model.LoginAttemptKey{Scope: model.LoginAttemptScopeIP, Key: "198.51.100.7"}
+	if _, _, err := repo.TryAttempt(ctx, ip, lockoutPolicy); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Lock(ctx, ip, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := throttle.attempt(ctx, []model.LoginAttemptKey{account, ip})
+	if !errors.Is(err, model.ErrLoginThrottled) {
+		t.Fatalf("attempt() from locked IP: got %v, want %v", err, model.ErrLoginThrottled)
+	}
+	// The account was counted first and must be given back.
+	if attempt, _ := repo.get(account); attempt.Failures != 0 {
+		t.Errorf("account failures = %d after a refused attempt, want 0", attempt.Failures)
+	}
+}
@@ -2,6 +2,9 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -13,20 +16,46 @@ import (
 )
 
 type OrderUsecase struct {
-	orderRepo   model.IOrderRepository
-	productRepo model.IProductRepository
-	orderClient order.OrderServiceClient
+	orderRepo       model.IOrderRepository
+	productRepo     model.IProductRepository
+	couponRepo      model.ICouponRepository
+	addressRepo     model.IAddressRepository
+	idempotencyRepo model.IIdempotencyKeyRepository
+	userRepo        model.IUserRepository
+	taxCalculator   model.TaxCalculator
+	shippingRates   model.ShippingRateProvider
+	idempotencyTTL  time.Duration
+	// requireVerifiedEmail rejects orders for users whose email is not
+	// verified.
+	requireVerifiedEmail bool
+	orderClient          order.OrderServiceClient
 }
 
 func NewOrderUsecase(
 	orderRepo model.IOrderRepository,
 	productRepo model.IProductRepository,
+	couponRepo model.ICouponRepository,
+	addressRepo model.IAddressRepository,
+	idempotencyRepo model.IIdempotencyKeyRepository,
+	userRepo model.IUserRepository,
+	taxCalculator model.TaxCalculator,
+	shippingRates model.ShippingRateProvider,
+	idempotencyTTL time.Duration,
+	requireVerifiedEmail bool,
 	orderClient order.OrderServiceClient,
 ) model.IOrderUsecase {
 	return &OrderUsecase{
-		orderRepo:   orderRepo,
-		productRepo: productRepo,
-		orderClient: orderClient,
+		orderRepo:            orderRepo,
+		productRepo:          productRepo,
+		couponRepo:           couponRepo,
+		addressRepo:          addressRepo,
+		idempotencyRepo:      idempotencyRepo,
+		userRepo:             userRepo,
+		taxCalculator:        taxCalculator,
+		shippingRates:        shippingRates,
+		idempotencyTTL:       idempotencyTTL,
+		requireVerifiedEmail: requireVerifiedEmail,
+		orderClient:          orderClient,
 	}
 }
 
@@ -37,7 +66,7 @@ func (u *OrderUsecase) FindAll(ctx context.Context, userID int64) ([]*model.Orde
 
 	if userID == 0 {
 		log.Error("Invalid user ID")
-		return nil, errors.New("invalid user ID")
+		return nil, model.ErrInvalidUserID
 	}
 
 	orders, err := u.orderRepo.FindAll(ctx, userID)
@@ -56,7 +85,7 @@ func (u *OrderUsecase) FindById(ctx context.Context, id string) (*model.Order, e
 
 	if id == "" {
 		log.Error("Invalid order ID")
-		return nil, errors.New("invalid order ID")
+		return nil, model.ErrInvalidOrderID
 	}
 
 	order, err := u.orderRepo.FindById(ctx, id)
@@ -91,7 +120,7 @@ func (u *OrderUsecase) Create(ctx context.Context, in model.CreateOrderInput) (*
 	})
 
 	if len(in.OrderItems) == 0 {
-		return nil, errors.New("order items cannot be empty")
+		return nil, model.ErrOrderItemsEmpty
 	}
 
 	err := helper.Validator.Struct(in)
@@ -100,33 +129,147 @@ func (u *OrderUsecase) Create(ctx context.Context, in model.CreateOrderInput) (*
 		return nil, err
 	}
 
-	order := model.Order{
+	if u.requireVerifiedEmail {
+		user, err := u.userRepo.FindById(ctx, in.UserID)
+		if err != nil {
+			log.Error("Failed to fetch order user: ", err)
+			return nil, err
+		}
+		if user.EmailVerifiedAt == nil {
+			return nil, model.ErrEmailNotVerified
+		}
+	}
+
+	if in.IdempotencyKey == "" {
+		return u.placeOrder(ctx, in)
+	}
+
+	requestHash, err := hashOrderInput(in)
+	if err != nil {
+		log.Error("Failed to hash order request: ", err)
+		return nil, err
+	}
+
+	record, created, err := u.idempotencyRepo.Reserve(ctx, model.IdempotencyKey{
 		UserID:      in.UserID,
-		TotalAmount: 0,
-		Status:      "pending",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		Key:         in.IdempotencyKey,
+		RequestHash: requestHash,
+		ExpiresAt:   time.Now().Add(u.idempotencyTTL),
+	})
+	if err != nil {
+		log.Error("Failed to reserve idempotency key: ", err)
+		return nil, err
 	}
 
+	if !created {
+		switch {
+		case record.RequestHash != requestHash:
+			return nil, model.ErrIdempotencyKeyReused
+		case record.OrderID == nil:
+			return nil, model.ErrIdempotencyKeyInProgress
+		}
+
+		log.Info("Replaying order for idempotency key: ", in.IdempotencyKey)
+		return u.orderRepo.FindById(ctx, *record.OrderID)
+	}
+
+	order, err := u.placeOrder(ctx, in)
+	if err != nil {
+		// Free the key so the client can retry the same request.
+		if releaseErr := u.idempotencyRepo.Release(ctx, in.UserID, in.IdempotencyKey); releaseErr != nil {
+			log.Error("Failed to release idempotency key: ", releaseErr)
+		}
+		return nil, err
+	}
+
+	if err := u.idempotencyRepo.Complete(ctx, in.UserID, in.IdempotencyKey, order.ID); err != nil {
+		log.Error("Failed to complete idempotency key: ", err)
+	}
+
+	return order, nil
+}
+
+func (u *OrderUsecase) placeOrder(ctx context.Context, in model.CreateOrderInput) (*model.Order, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"in": in,
+	})
+
+	order := model.Order{
+		UserID:       in.UserID,
+		Status:       model.OrderStatusPending,
+		StatusReason: "order created",
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	requested := make(map[int64]int64)
 	for _, item := range in.OrderItems {
 		product, err := u.productRepo.FindById(ctx, item.ProductID)
 		if err != nil {
 			log.Error("Product not found: ", item.ProductID)
-			return nil, errors.New("product not found")
+			return nil, model.ErrProductNotFound
+		}
+
+		// Fail fast on stock that is already short; the authoritative check
+		// happens under row lock in SaveOrder.
+		requested[item.ProductID] += item.Quantity
+		if requested[item.ProductID] > product.Stock {
+			log.Error("Insufficient stock for product: ", item.ProductID)
+			return nil, &model.InsufficientStockError{
+				ProductID: item.ProductID,
+				Requested: requested[item.ProductID],
+				Available: product.Stock,
+			}
+		}
+
+		if order.Currency == "" {
+			order.Currency = product.Currency
+		} else if order.Currency != product.Currency {
+			log.Error("Currency mismatch for product: ", item.ProductID)
+			return nil, model.ErrCurrencyMismatch
 		}
 
 		price := product.Price
-		order.TotalAmount += price * float64(item.Quantity)
+		order.Subtotal += price.Mul(item.Quantity)
 
 		order.OrderItems = append(order.OrderItems, model.OrderItem{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     price,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			ProductID:  item.ProductID,
+			Quantity:   item.Quantity,
+			Price:      price,
+			CategoryID: product.CategoryID,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
 		})
 	}
 
+	if err := u.snapshotAddresses(ctx, &order, in); err != nil {
+		log.Error("Failed to resolve order addresses: ", err)
+		return nil, err
+	}
+
+	if in.CouponCode != "" {
+		if err := u.applyCouponCode(ctx, &order, in.CouponCode); err != nil {
+			log.Error("Failed to apply coupon: ", err)
+			return nil, err
+		}
+	}
+
+	taxAmount, err := u.taxCalculator.CalculateTax(ctx, &order)
+	if err != nil {
+		log.Error("Failed to calculate tax: ", err)
+		return nil, err
+	}
+	order.TaxAmount = taxAmount
+
+	shippingAmount, err := u.shippingRates.ShippingRate(ctx, &order)
+	if err != nil {
+		log.Error("Failed to calculate shipping fee: ", err)
+		return nil, err
+	}
+	order.ShippingAmount = shippingAmount
+
+	order.TotalAmount = order.Subtotal - order.DiscountAmount + order.TaxAmount + order.ShippingAmount
+
 	if err := u.orderRepo.SaveOrder(ctx, &order); err != nil {
 		log.Error("Failed to save order: ", err)
 		return nil, err
@@ -138,7 +281,7 @@ func (u *OrderUsecase) Create(ctx context.Context, in model.CreateOrderInput) (*
 func (u *OrderUsecase) Update(ctx context.Context, order *model.Order) error {
 	if order == nil || order.ID == "" {
 		logrus.WithContext(ctx).Error("Invalid order: nil or empty ID")
-		return errors.New("invalid order: order is nil or has empty ID")
+		return model.ErrInvalidOrderID
 	}
 
 	log := logrus.WithFields(logrus.Fields{
@@ -169,14 +312,9 @@ func (u *OrderUsecase) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	if order == nil {
-		log.Error("Order not found")
-		return errors.New("order not found")
-	}
-
 	if order.DeletedAt != nil {
 		log.Error("Order already deleted")
-		return errors.New("order already deleted")
+		return model.ErrOrderNotFound
 	}
 
 	err = u.orderRepo.Delete(ctx, id)
@@ -189,13 +327,30 @@ func (u *OrderUsecase) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (u *OrderUsecase) UpdateOrderStatus(ctx context.Context, orderID string, status string) error {
+func (u *OrderUsecase) UpdateOrderStatus(ctx context.Context, orderID string, status string, reason string) error {
+	log := logrus.WithFields(logrus.Fields{
+		"order_id": orderID,
+		"status":   status,
+		"reason":   reason,
+	})
+
+	if !model.IsValidOrderStatus(status) {
+		log.Error("Invalid order status")
+		return model.ErrInvalidOrderStatus
+	}
+
 	order, err := u.FindById(ctx, orderID)
 	if err != nil {
-		return fmt.Errorf("order not found: %w", err)
+		return err
+	}
+
+	if !model.CanTransitionOrderStatus(order.Status, status) {
+		log.Errorf("Order cannot move from %s to %s", order.Status, status)
+		return &model.InvalidOrderStatusTransitionError{From: order.Status, To: status}
 	}
 
 	order.Status = status
+	order.StatusReason = reason
 
 	err = u.Update(ctx, order)
 	if err != nil {
@@ -205,6 +360,106 @@ func (u *OrderUsecase) UpdateOrderStatus(ctx context.Context, orderID string, st
 	return nil
 }
 
+// Cancel withdraws an order that has not been paid yet.
+func (u *OrderUsecase) Cancel(ctx context.Context, orderID string, reason string) error {
+	return u.UpdateOrderStatus(ctx, orderID, model.OrderStatusCancelled, reason)
+}
+
+// Refund returns a paid, processing or delivered order. Unshipped goods go
+// back to stock.
+func (u *OrderUsecase) Refund(ctx context.Context, orderID string, reason string) error {
+	return u.UpdateOrderStatus(ctx, orderID, model.OrderStatusRefunded, reason)
+}
+
+func (u *OrderUsecase) FindStatusHistory(ctx context.Context, orderID string) ([]*model.OrderStatusHistory, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"order_id": orderID,
+	})
+
+	if _, err := u.FindById(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	history, err := u.orderRepo.FindStatusHistory(ctx, orderID)
+	if err != nil {
+		log.Error("Failed to fetch order status history: ", err)
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// snapshotAddresses copies the chosen shipping and billing addresses onto the
+// order. Without a shipping address ID the user's default address is used,
+// if they have one.
+func (u *OrderUsecase) snapshotAddresses(ctx context.Context, order *model.Order, in model.CreateOrderInput) error {
+	var (
+		shipping *model.UserAddress
+		err      error
+	)
+	if in.ShippingAddressID != 0 {
+		shipping, err = u.addressRepo.FindById(ctx, order.UserID, in.ShippingAddressID)
+	} else {
+		shipping, err = u.addressRepo.FindDefault(ctx, order.UserID)
+		if errors.Is(err, model.ErrAddressNotFound) {
+			shipping, err = nil, nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if shipping != nil {
+		order.ShippingAddress = shipping.Snapshot()
+		order.BillingAddress = shipping.Snapshot()
+	}
+
+	if in.BillingAddressID != 0 {
+		billing, err := u.addressRepo.FindById(ctx, order.UserID, in.BillingAddressID)
+		if err != nil {
+			return err
+		}
+		order.BillingAddress = billing.Snapshot()
+	}
+
+	return nil
+}
+
+// applyCouponCode looks up the coupon and discounts the order with it. Usage
+// limits are checked again under lock when the order is saved.
+func (u *OrderUsecase) applyCouponCode(ctx context.Context, order *model.Order, code string) error {
+	coupon, err := u.couponRepo.FindByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	if coupon.UsageLimit != nil && coupon.UsedCount >= *coupon.UsageLimit {
+		return model.ErrCouponUsageExhausted
+	}
+
+	if coupon.PerUserLimit != nil {
+		used, err := u.couponRepo.CountRedemptionsByUser(ctx, coupon.ID, order.UserID)
+		if err != nil {
+			return err
+		}
+		if used >= *coupon.PerUserLimit {
+			return model.ErrCouponUsageExhausted
+		}
+	}
+
+	return applyCoupon(coupon, order, time.Now())
+}
+
+// hashOrderInput fingerprints the request body bound to an idempotency key.
+func hashOrderInput(in model.CreateOrderInput) (string, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 // func (u *OrderUsecase) getProductPrice(ctx context.Context, productID int64) (float64, error) {
 // 	var price float64
 // 	err := u.productRepo.GetPriceByID(ctx, productID, &price)
@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+// fakeIdempotencyRepo follows the rules of IdempotencyKeyRepo: a key is
+// held per user until it expires or is released.
+type fakeIdempotencyRepo struct {
+	records  map[string]*model.IdempotencyKey
+	released int
+}
+
+func newFakeIdempotencyRepo() *fakeIdempotencyRepo {
+	return &fakeIdempotencyRepo{records: make(map[string]*model.IdempotencyKey)}
+}
+
+func idempotencyRecordKey(userID int64, key string) string {
+	return fmt.Sprintf("%d/%s", userID, key)
+}
+
+func (r *fakeIdempotencyRepo) Reserve(_ context.Context, record model.IdempotencyKey) (*model.IdempotencyKey, bool, error) {
+	id := idempotencyRecordKey(record.UserID, record.Key)
+	if stored, ok := r.records[id]; ok && stored.ExpiresAt.After(time.Now()) {
+		copied := *stored
+		return &copied, false, nil
+	}
+
+	record.CreatedAt = time.Now()
+	r.records[id] = &record
+	copied := record
+	return &copied, true, nil
+}
+
+func (r *fakeIdempotencyRepo) Complete(_ context.Context, userID int64, key string, orderID string) error {
+	if stored, ok := r.records[idempotencyRecordKey(userID, key)]; ok {
+		stored.OrderID = &orderID
+	}
+	return nil
+}
+
+func (r *fakeIdempotencyRepo) Release(_ context.Context, userID int64, key string) error {
+	delete(r.records, idempotencyRecordKey(userID, key))
+	r.released++
+	return nil
+}
+
+// fakeOrderRepo stores saved orders in memory. SaveOrder fails with saveErr
+// when it is set.
+type fakeOrderRepo struct {
+	model.IOrderRepository
+	orders  map[string]*model.Order
+	saveErr error
+}
+
+func (r *fakeOrderRepo) SaveOrder(_ context.Context, order *model.Order) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	order.ID = fmt.Sprintf("ORD-%d", len(r.orders)+1)
+	copied := *order
+	r.orders[order.ID] = &copied
+	return nil
+}
+
+func (r *fakeOrderRepo) FindById(_ context.Context, id string) (*model.Order, error) {
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, model.ErrOrderNotFound
+	}
+	copied := *order
+	return &copied, nil
+}
+
+type fakeProductRepo struct {
+	model.IProductRepository
+	products map[int64]*model.Product
+}
+
+func (r *fakeProductRepo) FindById(_ context.Context, id int64) (*model.Product, error) {
+	product, ok := r.products[id]
+	if !ok {
+		return nil, model.ErrProductNotFound
+	}
+	copied := *product
+	return &copied, nil
+}
+
+// fakeAddressRepo has no addresses, so orders ship without one.
+type fakeAddressRepo struct {
+	model.IAddressRepository
+}
+
+func (fakeAddressRepo) FindDefault(_ context.Context, _ int64) (*model.UserAddress, error) {
+	return nil, model.ErrAddressNotFound
+}
+
+func newOrderTestUsecase() (*OrderUsecase, *fakeOrderRepo, *fakeProductRepo, *fakeIdempotencyRepo) {
+	orders := &fakeOrderRepo{orders: make(map[string]*model.Order)}
+	products := &fakeProductRepo{products: map[int64]*model.Product{
+		1: {ID: 1, Name: "Kopi", Price: 2500000, Currency: "IDR", Stock: 10},
+	}}
+	idempotency := newFakeIdempotencyRepo()
+
+	u := &OrderUsecase{
+		orderRepo:       orders,
+		productRepo:     products,
+		addressRepo:     fakeAddressRepo{},
+		idempotencyRepo: idempotency,
+		taxCalculator:   NewTableTaxCalculator(0, nil),
+		shippingRates:   NewTableShippingRateProvider("IDR", nil),
+		idempotencyTTL:  time.Hour,
+	}
+	return u, orders, products, idempotency
+}
+
+func orderInput(userID int64, quantity int64, key string) model.CreateOrderInput {
+	return model.CreateOrderInput{
+		UserID:         userID,
+		OrderItems:     []model.CreateOrderItem{{ProductID: 1, Quantity: quantity}},
+		IdempotencyKey: key,
+	}
+}
+
+func TestCreateOrderReplaysIdempotencyKey(t *testing.T) {
+	u, orders, _, _ := newOrderTestUsecase()
+	ctx := context.Background()
+
+	first, err := u.Create(ctx, orderInput(1, 2, "checkout-1"))
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	replayed, err := u.Create(ctx, orderInput(1, 2, "checkout-1"))
+	if err != nil {
+		t.Fatalf("replayed request: %v", err)
+	}
+
+	if replayed.ID != first.ID {
+		t.Errorf("replayed order ID = %s, want %s", replayed.ID, first.ID)
+	}
+	if replayed.TotalAmount != first.TotalAmount {
+		t.Errorf("replayed total = %s, want %s", replayed.TotalAmount, first.TotalAmount)
+	}
+	if len(orders.orders) != 1 {
+		t.Errorf("%d orders saved, want 1", len(orders.orders))
+	}
+}
+
+func TestCreateOrderIdempotencyKeyConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		second  model.CreateOrderInput
+		wantErr error
+	}{
+		{
+			name:    "different payload",
+			second:  orderInput(1, 3, "checkout-1"),
+			wantErr: model.ErrIdempotencyKeyReused,
+		},
+		{
+			name:    "different coupon",
+			second:  model.CreateOrderInput{UserID: 1, OrderItems: orderInput(1, 2, "").OrderItems, CouponCode: "HEMAT", IdempotencyKey: "checkout-1"},
+			wantErr: model.ErrIdempotencyKeyReused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, orders, _, _ := newOrderTestUsecase()
+			ctx := context.Background()
+
+			if _, err := u.Create(ctx, orderInput(1, 2, "checkout-1")); err != nil {
+				t.Fatalf("first request: %v", err)
+			}
+			_, err := u.Create(ctx, tt.second)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("second request: got %v, want %v", err, tt.wantErr)
+			}
+			if len(orders.orders) != 1 {
+				t.Errorf("%d orders saved, want 1", len(orders.orders))
+			}
+		})
+	}
+}
+
+func TestCreateOrderIdempotencyKeyInProgress(t *testing.T) {
+	u, orders, _, idempotency := newOrderTestUsecase()
+	ctx := context.Background()
+
+	in := orderInput(1, 2, "checkout-1")
+	hash, err := hashOrderInput(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reserved by a request that has not finished yet.
+	if _, _, err := idempotency.Reserve(ctx, model.IdempotencyKey{
+		UserID:      1,
+		Key:         "checkout-1",
+		RequestHash: hash,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := u.Create(ctx, in); !errors.Is(err, model.ErrIdempotencyKeyInProgress) {
+		t.Fatalf("got %v, want %v", err, model.ErrIdempotencyKeyInProgress)
+	}
+	if len(orders.orders) != 0 {
+		t.Errorf("%d orders saved, want 0", len(orders.orders))
+	}
+}
+
+func TestCreateOrderIdempotencyKeyIsPerUser(t *testing.T) {
+	u, orders, _, _ := newOrderTestUsecase()
+	ctx := context.Background()
+
+	first, err := u.Create(ctx, orderInput(1, 2, "checkout-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := u.Create(ctx, orderInput(2, 2, "checkout-1"))
+	if err != nil {
+		t.Fatalf("other user with the same key: %v", err)
+	}
+
+	if other.ID == first.ID || other.UserID != 2 {
+		t.Errorf("other user got order %s of user %d, want a new order", other.ID, other.UserID)
+	}
+	if len(orders.orders) != 2 {
+		t.Errorf("%d orders saved, want 2", len(orders.orders))
+	}
+}
+
+func TestCreateOrderReleasesKeyOnFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    func(orders *fakeOrderRepo, products *fakeProductRepo)
+		restore func(orders *fakeOrderRepo, products *fakeProductRepo)
+	}{
+		{
+			name:    "insufficient stock",
+			fail:    func(_ *fakeOrderRepo, products *fakeProductRepo) { products.products[1].Stock = 1 },
+			restore: func(_ *fakeOrderRepo, products *fakeProductRepo) { products.products[1].Stock = 10 },
+		},
+		{
+			name:    "save fails",
+			fail:    func(orders *fakeOrderRepo, _ *fakeProductRepo) { orders.saveErr = errors.New("connection reset") },
+			restore: func(orders *fakeOrderRepo, _ *fakeProductRepo) { orders.saveErr = nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, orders, products, idempotency := newOrderTestUsecase()
+			ctx := context.Background()
+			in := orderInput(1, 2, "checkout-1")
+
+			tt.fail(orders, products)
+			if _, err := u.Create(ctx, in); err == nil {
+				t.Fatal("Create() succeeded, want an error")
+			}
+			if idempotency.released != 1 {
+				t.Fatalf("key released %d times, want 1", idempotency.released)
+			}
+
+			// The same request can be retried once the cause is gone.
+			tt.restore(orders, products)
+			order, err := u.Create(ctx, in)
+			if err != nil {
+				t.Fatalf("retry: %v", err)
+			}
+			if stored := idempotency.records[idempotencyRecordKey(1, "checkout-1")]; stored == nil || stored.OrderID == nil || *stored.OrderID != order.ID {
+				t.Errorf("key not completed with order %s after the retry", order.ID)
+			}
+		})
+	}
+}
+
+func TestCreateOrderWithoutIdempotencyKey(t *testing.T) {
+	u, orders, _, idempotency := newOrderTestUsecase()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := u.Create(ctx, orderInput(1, 2, "")); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if len(orders.orders) != 2 {
+		t.Errorf("%d orders saved, want 2", len(orders.orders))
+	}
+	if len(idempotency.records) != 0 {
+		t.Errorf("%d idempotency keys reserved, want 0", len(idempotency.records))
+	}
+}
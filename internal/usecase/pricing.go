@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+const basisPoints = 10000
+
+// TableTaxCalculator charges a rate in basis points on each item's discounted
+// line total, using the rate configured for the item's category or the
+// default rate otherwise. The order tax is rounded half up once, so it does
+// not depend on how items are split into lines.
+type TableTaxCalculator struct {
+	defaultRate   int64
+	categoryRates map[int64]int64
+}
+
+func NewTableTaxCalculator(defaultRate int64, categoryRates map[int64]int64) model.TaxCalculator {
+	return &TableTaxCalculator{
+		defaultRate:   defaultRate,
+		categoryRates: categoryRates,
+	}
+}
+
+func (c *TableTaxCalculator) CalculateTax(ctx context.Context, order *model.Order) (model.Money, error) {
+	var scaled model.Money
+	for _, item := range order.OrderItems {
+		rate, ok := c.categoryRates[item.CategoryID]
+		if !ok {
+			rate = c.defaultRate
+		}
+
+		taxable := item.Price.Mul(item.Quantity) - item.DiscountAmount
+		scaled += taxable * model.Money(rate)
+	}
+
+	return (scaled + basisPoints/2) / basisPoints, nil
+}
+
+// TableShippingRateProvider charges the fee of the highest tier whose minimum
+// the discounted subtotal reaches. Orders below every tier ship free. Tiers
+// are priced in a single currency.
+type TableShippingRateProvider struct {
+	currency string
+	tiers    []model.ShippingTier
+}
+
+func NewTableShippingRateProvider(currency string, tiers []model.ShippingTier) model.ShippingRateProvider {
+	sorted := make([]model.ShippingTier, len(tiers))
+	copy(sorted, tiers)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].MinSubtotal < sorted[j].MinSubtotal
+	})
+
+	return &TableShippingRateProvider{
+		currency: currency,
+		tiers:    sorted,
+	}
+}
+
+func (p *TableShippingRateProvider) ShippingRate(ctx context.Context, order *model.Order) (model.Money, error) {
+	if len(p.tiers) == 0 {
+		return 0, nil
+	}
+
+	if order.Currency != p.currency {
+		return 0, model.ErrCurrencyMismatch
+	}
+
+	amount := order.Subtotal - order.DiscountAmount
+
+	var fee model.Money
+	for _, tier := range p.tiers {
+		if amount < tier.MinSubtotal {
+			break
+		}
+		fee = tier.Fee
+	}
+
+	return fee, nil
+}
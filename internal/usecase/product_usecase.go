@@ -2,7 +2,6 @@ package usecase
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -12,17 +11,20 @@ import (
 )
 
 type ProductUsecase struct {
-	productRepo   model.IProductRepository
-	productClient product.ProductServiceClient
+	productRepo     model.IProductRepository
+	defaultCurrency string
+	productClient   product.ProductServiceClient
 }
 
 func NewProductUsecase(
 	productRepo model.IProductRepository,
+	defaultCurrency string,
 	productClient product.ProductServiceClient,
 ) model.IProductUsecase {
 	return &ProductUsecase{
-		productRepo:   productRepo,
-		productClient: productClient,
+		productRepo:     productRepo,
+		defaultCurrency: defaultCurrency,
+		productClient:   productClient,
 	}
 }
 
@@ -66,16 +68,23 @@ func (u *ProductUsecase) Create(ctx context.Context, in model.CreateProductInput
 	}
 
 	if in.Name == "" || in.Price <= 0 || in.Stock < 0 || in.ImageUrl == "" {
-		return model.Product{}, errors.New("invalid product data")
+		return model.Product{}, model.ErrInvalidProduct
+	}
+
+	currency := in.Currency
+	if currency == "" {
+		currency = u.defaultCurrency
 	}
 
 	product := model.Product{
 		Name:        in.Name,
 		Description: in.Description,
 		Price:       in.Price,
+		Currency:    currency,
 		Stock:       in.Stock,
 		CategoryID:  in.CategoryID,
 		ImageUrl:    in.ImageUrl,
+		SellerID:    in.SellerID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -108,6 +117,9 @@ func (u *ProductUsecase) Update(ctx context.Context, id int64, in model.UpdatePr
 	existingProduct.Name = in.Name
 	existingProduct.Description = in.Description
 	existingProduct.Price = in.Price
+	if in.Currency != "" {
+		existingProduct.Currency = in.Currency
+	}
 	existingProduct.Stock = in.Stock
 	existingProduct.CategoryID = in.CategoryID
 	existingProduct.ImageUrl = in.ImageUrl
@@ -132,14 +144,9 @@ func (u *ProductUsecase) Delete(ctx context.Context, id int64) error {
 		return err
 	}
 
-	if product == nil {
-		log.Error("Product not found")
-		return errors.New("product not found")
-	}
-
 	if product.DeletedAt != nil {
 		log.Error("Product already deleted")
-		return errors.New("product already deleted")
+		return model.ErrProductNotFound
 	}
 
 	err = u.productRepo.Delete(ctx, id)
@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+type RoleUsecase struct {
+	roleRepo    model.IRoleRepository
+	userRepo    model.IUserRepository
+	userUsecase model.IUserUsecase
+}
+
+func NewRoleUsecase(roleRepo model.IRoleRepository, userRepo model.IUserRepository, userUsecase model.IUserUsecase) model.IRoleUsecase {
+	return &RoleUsecase{
+		roleRepo:    roleRepo,
+		userRepo:    userRepo,
+		userUsecase: userUsecase,
+	}
+}
+
+func (u *RoleUsecase) ChangeRole(ctx context.Context, userID int64, in model.ChangeRoleInput) (*model.RoleChange, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+		"in":      in,
+	})
+
+	claim, err := requirePermission(ctx, model.PermissionUsersManage)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, err
+	}
+
+	if claim.UserID == userID {
+		return nil, model.ErrCannotChangeOwnRole
+	}
+
+	actorUserID, actorService := model.ActorFromContext(ctx)
+	change := model.RoleChange{
+		UserID:       userID,
+		ToRole:       in.Role,
+		ActorUserID:  actorUserID,
+		ActorService: actorService,
+		Reason:       in.Reason,
+	}
+
+	if err := u.roleRepo.ChangeRole(ctx, &change); err != nil {
+		log.Error("Failed to change user role: ", err)
+		return nil, err
+	}
+
+	if err := u.endSessions(ctx, change); err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// endSessions revokes the sessions of a user whose role changed, since
+// their access tokens and cached sessions still carry the old role.
+func (u *RoleUsecase) endSessions(ctx context.Context, change model.RoleChange) error {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": change.UserID,
+	})
+
+	if change.FromRole == change.ToRole {
+		log.Infof("User role is already %s", change.ToRole)
+		return nil
+	}
+
+	log.Infof("User role changed from %s to %s", change.FromRole, change.ToRole)
+	if err := u.userUsecase.RevokeAllSessions(ctx, change.UserID); err != nil {
+		log.Error("Failed to revoke sessions after role change: ", err)
+		return err
+	}
+	return nil
+}
+
+func (u *RoleUsecase) FindRoleChanges(ctx context.Context, userID int64) ([]*model.RoleChange, error) {
+	if _, err := requirePermission(ctx, model.PermissionUsersManage); err != nil {
+		return nil, err
+	}
+
+	changes, err := u.roleRepo.FindRoleChanges(ctx, userID)
+	if err != nil {
+		logrus.WithField("user_id", userID).Error("Failed to fetch role changes: ", err)
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (u *RoleUsecase) ApplyForSeller(ctx context.Context, userID int64, in model.SellerApplicationInput) (*model.SellerApplication, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+		"in":      in,
+	})
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, err
+	}
+
+	user, err := u.userRepo.FindById(ctx, userID)
+	if err != nil {
+		log.Error("Failed to fetch user: ", err)
+		return nil, err
+	}
+
+	if user.Role != model.RoleCustomer {
+		return nil, model.ErrSellerApplicationNotAvailable
+	}
+
+	application := model.SellerApplication{
+		UserID:    userID,
+		StoreName: in.StoreName,
+		Note:      in.Note,
+		Status:    model.SellerApplicationPending,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := u.roleRepo.CreateSellerApplication(ctx, &application); err != nil {
+		log.Error("Failed to create seller application: ", err)
+		return nil, err
+	}
+
+	return &application, nil
+}
+
+func (u *RoleUsecase) FindSellerApplications(ctx context.Context, status string) ([]*model.SellerApplication, error) {
+	if _, err := requirePermission(ctx, model.PermissionUsersManage); err != nil {
+		return nil, err
+	}
+
+	applications, err := u.roleRepo.FindSellerApplications(ctx, status)
+	if err != nil {
+		logrus.WithField("status", status).Error("Failed to fetch seller applications: ", err)
+		return nil, err
+	}
+
+	return applications, nil
+}
+
+func (u *RoleUsecase) ApproveSellerApplication(ctx context.Context, id int64, in model.ReviewSellerApplicationInput) (*model.SellerApplication, error) {
+	return u.reviewSellerApplication(ctx, id, model.SellerApplicationApproved, in)
+}
+
+func (u *RoleUsecase) RejectSellerApplication(ctx context.Context, id int64, in model.ReviewSellerApplicationInput) (*model.SellerApplication, error) {
+	return u.reviewSellerApplication(ctx, id, model.SellerApplicationRejected, in)
+}
+
+// reviewSellerApplication closes a pending application. Approval promotes
+// the applicant to seller, recorded in the role audit trail.
+func (u *RoleUsecase) reviewSellerApplication(ctx context.Context, id int64, status string, in model.ReviewSellerApplicationInput) (*model.SellerApplication, error) {
+	log := logrus.WithFields(logrus.Fields{
+		"id":     id,
+		"status": status,
+	})
+
+	claim, err := requirePermission(ctx, model.PermissionUsersManage)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := helper.Validator.Struct(in); err != nil {
+		log.Error("Validation error:", err)
+		return nil, err
+	}
+
+	application, err := u.roleRepo.FindSellerApplicationById(ctx, id)
+	if err != nil {
+		log.Error("Failed to fetch seller application: ", err)
+		return nil, err
+	}
+
+	if application.Status != model.SellerApplicationPending {
+		return nil, model.ErrSellerApplicationReviewed
+	}
+
+	now := time.Now()
+	application.Status = status
+	application.ReviewedBy = &claim.UserID
+	application.ReviewNote = in.Note
+	application.ReviewedAt = &now
+	application.UpdatedAt = now
+
+	var change *model.RoleChange
+	if status == model.SellerApplicationApproved {
+		actorUserID, actorService := model.ActorFromContext(ctx)
+		change = &model.RoleChange{
+			UserID:       application.UserID,
+			ToRole:       model.RoleSeller,
+			ActorUserID:  actorUserID,
+			ActorService: actorService,
+			Reason:       "seller application approved",
+		}
+	}
+
+	if err := u.roleRepo.ReviewSellerApplication(ctx, *application, change); err != nil {
+		log.Error("Failed to review seller application: ", err)
+		return nil, err
+	}
+
+	if change != nil {
+		if err := u.endSessions(ctx, *change); err != nil {
+			return nil, err
+		}
+	}
+
+	return application, nil
+}
+
+// requirePermission returns the caller's claims when the access policy
+// grants them permission, and ErrForbidden otherwise.
+func requirePermission(ctx context.Context, permission model.Permission) (model.CustomClaims, error) {
+	claim, ok := ctx.Value(model.BearerAuthKey).(model.CustomClaims)
+	if !ok || !claim.Can(permission) {
+		return model.CustomClaims{}, model.ErrForbidden
+	}
+	return claim, nil
+}
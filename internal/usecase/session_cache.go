@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+// sessionCacheSize bounds the number of cached sessions.
+const sessionCacheSize = 10000
+
+type sessionCacheEntry struct {
+	session   model.UserSession
+	expiresAt time.Time
+}
+
+// sessionCache remembers recently validated sessions for a short time so
+// authenticated requests don't each hit user_sessions. A session revoked on
+// another instance stays valid here for at most ttl.
+type sessionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]sessionCacheEntry
+}
+
+func newSessionCache(ttl time.Duration) *sessionCache {
+	return &sessionCache{
+		ttl:     ttl,
+		entries: make(map[string]sessionCacheEntry),
+	}
+}
+
+func (c *sessionCache) get(token string) (*model.UserSession, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[token]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, token)
+		return nil, false
+	}
+
+	session := entry.session
+	return &session, true
+}
+
+func (c *sessionCache) put(token string, session model.UserSession) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	expiresAt := time.Now().Add(c.ttl)
+	if session.ExpiresAt.Before(expiresAt) {
+		expiresAt = session.ExpiresAt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= sessionCacheSize {
+		c.evict()
+	}
+	c.entries[token] = sessionCacheEntry{session: session, expiresAt: expiresAt}
+}
+
+func (c *sessionCache) delete(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, token)
+}
+
+// evict drops expired entries, and if the cache is still full, an arbitrary
+// half of the rest. The caller must hold c.mu.
+func (c *sessionCache) evict() {
+	now := time.Now()
+	for token, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, token)
+		}
+	}
+
+	for token := range c.entries {
+		if len(c.entries) < sessionCacheSize/2 {
+			break
+		}
+		delete(c.entries, token)
+	}
+}
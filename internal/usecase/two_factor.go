@@ -0,0 +1,377 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+const recoveryCodeCount = 10
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// finishLogin runs once the password has been checked. It starts the
+// session, or issues a challenge when the account needs a second factor.
+func (u *UserUsecase) finishLogin(ctx context.Context, user *model.User, userAgent string, ipAddress string) (*model.LoginResult, error) {
+	totp, err := u.twoFactorRepo.FindTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := totp != nil && totp.ConfirmedAt != nil
+	if !enabled && !u.twoFactorRequired(user) {
+		tokens, err := u.startSession(ctx, user, userAgent, ipAddress)
+		if err != nil {
+			return nil, err
+		}
+		return &model.LoginResult{Tokens: tokens}, nil
+	}
+
+	token, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(u.twoFactor.ChallengeTTL)
+	err = u.twoFactorRepo.CreateLoginChallenge(ctx, model.LoginChallenge{
+		UserID:    user.ID,
+		TokenHash: helper.HashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginResult{
+		Challenge: &model.TwoFactorChallenge{
+			ChallengeToken:     token,
+			ExpiresAt:          expiresAt,
+			EnrollmentRequired: !enabled,
+		},
+	}, nil
+}
+
+func (u *UserUsecase) twoFactorRequired(user *model.User) bool {
+	return u.twoFactor.RequireForAdmins && user.Role == model.RoleAdmin
+}
+
+// CompleteLogin exchanges a login challenge and a TOTP or recovery code for
+// the session. For an account that had to enroll during login, the first
+// valid code also confirms the enrollment and the recovery codes are
+// returned.
+func (u *UserUsecase) CompleteLogin(ctx context.Context, in model.TwoFactorLoginInput) (*model.LoginResult, error) {
+	if err := helper.Validator.StructCtx(ctx, in); err != nil {
+		return nil, err
+	}
+
+	challenge, err := u.twoFactorRepo.FindLoginChallenge(ctx, helper.HashToken(in.ChallengeToken))
+	if err != nil {
+		return nil, err
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": challenge.UserID,
+	})
+
+	user, err := u.userRepo.FindById(ctx, challenge.UserID)
+	if err != nil {
+		log.Error("Failed to fetch user: ", err)
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	err = u.checkCodeThrottled(ctx, user, func() error {
+		totp, err := u.twoFactorRepo.FindTOTP(ctx, challenge.UserID)
+		if err != nil {
+			return err
+		}
+		if totp == nil {
+			return model.ErrTwoFactorEnrollmentPending
+		}
+
+		if totp.ConfirmedAt == nil {
+			recoveryCodes, err = u.confirmTOTP(ctx, totp, in.Code)
+			return err
+		}
+		return u.verifySecondFactor(ctx, totp, in.Code)
+	})
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidTwoFactorCode) {
+			if failErr := u.twoFactorRepo.FailLoginChallenge(ctx, challenge.ID, u.twoFactor.MaxChallengeAttempts); failErr != nil {
+				log.Error("Failed to record challenge attempt: ", failErr)
+			}
+		} else if !errors.Is(err, model.ErrLoginThrottled) && !errors.Is(err, model.ErrTwoFactorEnrollmentPending) {
+			log.Error("Failed to verify second factor: ", err)
+		}
+		return nil, err
+	}
+
+	if err := u.twoFactorRepo.DeleteLoginChallenge(ctx, challenge.ID); err != nil {
+		log.Error("Failed to delete login challenge: ", err)
+		return nil, err
+	}
+
+	tokens, err := u.startSession(ctx, user, in.UserAgent, in.IPAddress)
+	if err != nil {
+		log.Error("Failed to start session: ", err)
+		return nil, err
+	}
+
+	return &model.LoginResult{Tokens: tokens, RecoveryCodes: recoveryCodes}, nil
+}
+
+// EnrollTOTP starts enrollment for a logged in user. It has to be confirmed
+// with ConfirmTOTP before it is used.
+func (u *UserUsecase) EnrollTOTP(ctx context.Context, userID int64) (*model.TOTPEnrollment, error) {
+	user, err := u.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := helper.EncryptSecret(u.twoFactor.EncryptionKey, secret)
+	if err != nil {
+		logrus.Error("Failed to encrypt TOTP secret: ", err)
+		return nil, err
+	}
+
+	err = u.twoFactorRepo.SaveTOTP(ctx, model.UserTOTP{
+		UserID:          userID,
+		SecretEncrypted: encrypted,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURI: helper.TOTPURI(u.twoFactor.Issuer, user.Email, secret),
+	}, nil
+}
+
+// EnrollTOTPWithChallenge starts enrollment for an account that must have
+// two-factor authentication before it can log in.
+func (u *UserUsecase) EnrollTOTPWithChallenge(ctx context.Context, in model.TwoFactorChallengeInput) (*model.TOTPEnrollment, error) {
+	if err := helper.Validator.StructCtx(ctx, in); err != nil {
+		return nil, err
+	}
+
+	challenge, err := u.twoFactorRepo.FindLoginChallenge(ctx, helper.HashToken(in.ChallengeToken))
+	if err != nil {
+		return nil, err
+	}
+
+	return u.EnrollTOTP(ctx, challenge.UserID)
+}
+
+// ConfirmTOTP enables a pending enrollment and returns the recovery codes,
+// which are shown only this once.
+func (u *UserUsecase) ConfirmTOTP(ctx context.Context, userID int64, in model.TwoFactorCodeInput) ([]string, error) {
+	if err := helper.Validator.StructCtx(ctx, in); err != nil {
+		return nil, err
+	}
+
+	totp, err := u.twoFactorRepo.FindTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil {
+		return nil, model.ErrTwoFactorNotEnrolled
+	}
+	if totp.ConfirmedAt != nil {
+		return nil, model.ErrTwoFactorAlreadyEnabled
+	}
+
+	user, err := u.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = u.checkCodeThrottled(ctx, user, func() error {
+		codes, err = u.confirmTOTP(ctx, totp, in.Code)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP removes two-factor authentication after checking a current
+// code. Accounts that require it cannot disable it.
+func (u *UserUsecase) DisableTOTP(ctx context.Context, userID int64, in model.TwoFactorCodeInput) error {
+	if err := helper.Validator.StructCtx(ctx, in); err != nil {
+		return err
+	}
+
+	user, err := u.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.twoFactorRequired(user) {
+		return model.ErrTwoFactorRequired
+	}
+
+	totp, err := u.twoFactorRepo.FindTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if totp == nil || totp.ConfirmedAt == nil {
+		return model.ErrTwoFactorNotEnrolled
+	}
+
+	err = u.checkCodeThrottled(ctx, user, func() error {
+		return u.verifySecondFactor(ctx, totp, in.Code)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := u.twoFactorRepo.DeleteTOTP(ctx, userID); err != nil {
+		logrus.WithField("user_id", userID).Error("Failed to disable TOTP: ", err)
+		return err
+	}
+	return nil
+}
+
+// checkCodeThrottled runs check, which verifies a code the user typed,
+// against the account counter of the login throttle. Wrong codes count like
+// wrong passwords, so neither TOTP nor recovery codes can be guessed faster
+// than a password, whether at login or with a stolen session. A right code
+// clears the counter.
+func (u *UserUsecase) checkCodeThrottled(ctx context.Context, user *model.User, check func() error) error {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": user.ID,
+	})
+
+	accountKey := accountAttemptKey(user.Email)
+	attempts, err := u.loginThrottle.attempt(ctx, []model.LoginAttemptKey{accountKey})
+	if err != nil {
+		if !errors.Is(err, model.ErrLoginThrottled) {
+			log.Error("Failed to check login attempts: ", err)
+		}
+		return err
+	}
+
+	if err := check(); err != nil {
+		if errors.Is(err, model.ErrInvalidTwoFactorCode) {
+			u.loginThrottle.recordFailure(ctx, attempts)
+		} else {
+			u.loginThrottle.release(ctx, attempts)
+		}
+		return err
+	}
+
+	if err := u.loginThrottle.reset(ctx, accountKey); err != nil {
+		log.Error("Failed to reset login attempts: ", err)
+	}
+	return nil
+}
+
+func (u *UserUsecase) confirmTOTP(ctx context.Context, totp *model.UserTOTP, code string) ([]string, error) {
+	secret, err := helper.DecryptSecret(u.twoFactor.EncryptionKey, totp.SecretEncrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := helper.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, model.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.twoFactorRepo.ConfirmTOTP(ctx, totp.UserID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifySecondFactor accepts a TOTP code, each step only once, or an
+// unused recovery code.
+func (u *UserUsecase) verifySecondFactor(ctx context.Context, totp *model.UserTOTP, code string) error {
+	if isTOTPCode(code) {
+		secret, err := helper.DecryptSecret(u.twoFactor.EncryptionKey, totp.SecretEncrypted)
+		if err != nil {
+			return err
+		}
+
+		step, ok := helper.ValidateTOTP(secret, code, time.Now())
+		if !ok {
+			return model.ErrInvalidTwoFactorCode
+		}
+
+		fresh, err := u.twoFactorRepo.UseTOTPStep(ctx, totp.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return model.ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := u.twoFactorRepo.UseRecoveryCode(ctx, totp.UserID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return model.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func isTOTPCode(code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != 6 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRecoveryCodes returns codes formatted as "abcd-efgh" and their
+// hashes for storage.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+		hashes = append(hashes, hashRecoveryCode(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so codes can be typed
+// loosely.
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	return helper.HashToken(normalized)
+}
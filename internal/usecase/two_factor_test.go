@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
+)
+
+// fakeTwoFactorRepo keeps one enrollment in memory and the last used TOTP
+// step with the same rule as the database. Other methods are not used by
+// these tests.
+type fakeTwoFactorRepo struct {
+	model.ITwoFactorRepository
+	totp         *model.UserTOTP
+	lastUsedStep int64
+}
+
+func (r *fakeTwoFactorRepo) FindTOTP(_ context.Context, _ int64) (*model.UserTOTP, error) {
+	if r.totp == nil {
+		return nil, nil
+	}
+	copied := *r.totp
+	return &copied, nil
+}
+
+func (r *fakeTwoFactorRepo) ConfirmTOTP(_ context.Context, _ int64, step int64, _ []string) error {
+	now := time.Now()
+	r.totp.ConfirmedAt = &now
+	r.lastUsedStep = step
+	return nil
+}
+
+func (r *fakeTwoFactorRepo) UseTOTPStep(_ context.Context, _ int64, step int64) (bool, error) {
+	if step <= r.lastUsedStep {
+		return false, nil
+	}
+	r.lastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeTwoFactorRepo) UseRecoveryCode(_ context.Context, _ int64, _ string) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeTwoFactorRepo) DeleteTOTP(_ context.Context, _ int64) error {
+	r.totp = nil
+	return nil
+}
+
+// totpAt computes the RFC 6238 code of secret for step, independently of
+// the helper package.
+func totpAt(t *testing.T, secret string, step int64) string {
+	t.Helper()
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+func newTOTPTestUsecase(t *testing.T) (*UserUsecase, *model.UserTOTP, string) {
+	t.Helper()
+
+	key := make([]byte, 32)
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := helper.EncryptSecret(key, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totp := &model.UserTOTP{UserID: 1, SecretEncrypted: encrypted}
+	u := &UserUsecase{
+		userRepo:      newFakeUserRepo(&model.User{ID: 1, Email: "user@example.com", Role: model.RoleCustomer}),
+		loginThrottle: newLoginThrottle(newFakeLoginAttemptRepo(), testLoginPolicy),
+		twoFactorRepo: &fakeTwoFactorRepo{totp: totp},
+		twoFactor:     model.TwoFactorPolicy{EncryptionKey: key},
+	}
+	return u, totp, secret
+}
+
+func TestVerifySecondFactorRejectsStepReuse(t *testing.T) {
+	u, totp, secret := newTOTPTestUsecase(t)
+	code := totpAt(t, secret, helper.TOTPStep(time.Now()))
+
+	if err := u.verifySecondFactor(context.Background(), totp, code); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := u.verifySecondFactor(context.Background(), totp, code); !errors.Is(err, model.ErrInvalidTwoFactorCode) {
+		t.Fatalf("replayed code: got %v, want %v", err, model.ErrInvalidTwoFactorCode)
+	}
+}
+
+func TestVerifySecondFactorRejectsOlderStep(t *testing.T) {
+	u, totp, secret := newTOTPTestUsecase(t)
+	current := helper.TOTPStep(time.Now())
+
+	if err := u.verifySecondFactor(context.Background(), totp, totpAt(t, secret, current)); err != nil {
+		t.Fatalf("current step: %v", err)
+	}
+	// Still inside the skew window, but older than the step just used.
+	err := u.verifySecondFactor(context.Background(), totp, totpAt(t, secret, current-1))
+	if !errors.Is(err, model.ErrInvalidTwoFactorCode) {
+		t.Fatalf("previous step after current: got %v, want %v", err, model.ErrInvalidTwoFactorCode)
+	}
+}
+
+// wrongCode is a well-formed code of a step far outside the accepted window.
+func wrongCode(t *testing.T, secret string) string {
+	t.Helper()
+	return totpAt(t, secret, helper.TOTPStep(time.Now())+100)
+}
+
+func TestDisableTOTPThrottlesWrongCodes(t *testing.T) {
+	u, totp, secret := newTOTPTestUsecase(t)
+	now := time.Now()
+	totp.ConfirmedAt = &now
+	ctx := context.Background()
+
+	guesses := []string{wrongCode(t, secret), "abcd-efgh", wrongCode(t, secret), "ijkl-mnop"}
+	for i, code := range guesses {
+		err := u.DisableTOTP(ctx, 1, model.TwoFactorCodeInput{Code: code})
+		if !errors.Is(err, model.ErrInvalidTwoFactorCode) {
+			t.Fatalf("guess %d: got %v, want %v", i+1, err, model.ErrInvalidTwoFactorCode)
+		}
+	}
+
+	// Even the right code is refused until the backoff is over.
+	code := totpAt(t, secret, helper.TOTPStep(time.Now()))
+	if err := u.DisableTOTP(ctx, 1, model.TwoFactorCodeInput{Code: code}); !errors.Is(err, model.ErrLoginThrottled) {
+		t.Fatalf("after %d wrong codes: got %v, want %v", len(guesses), err, model.ErrLoginThrottled)
+	}
+	if enrolled, _ := u.twoFactorRepo.FindTOTP(ctx, 1); enrolled == nil {
+		t.Fatal("TOTP was disabled while throttled")
+	}
+}
+
+func TestConfirmTOTPThrottlesWrongCodes(t *testing.T) {
+	u, _, secret := newTOTPTestUsecase(t)
+	ctx := context.Background()
+
+	for i := 0; i < int(testLoginPolicy.FreeAttempts)+1; i++ {
+		_, err := u.ConfirmTOTP(ctx, 1, model.TwoFactorCodeInput{Code: wrongCode(t, secret)})
+		if !errors.Is(err, model.ErrInvalidTwoFactorCode) {
+			t.Fatalf("guess %d: got %v, want %v", i+1, err, model.ErrInvalidTwoFactorCode)
+		}
+	}
+
+	_, err := u.ConfirmTOTP(ctx, 1, model.TwoFactorCodeInput{Code: wrongCode(t, secret)})
+	if !errors.Is(err, model.ErrLoginThrottled) {
+		t.Fatalf("got %v, want %v", err, model.ErrLoginThrottled)
+	}
+}
+
+func TestDisableTOTPRightCodeResetsThrottle(t *testing.T) {
+	u, totp, secret := newTOTPTestUsecase(t)
+	now := time.Now()
+	totp.ConfirmedAt = &now
+	ctx := context.Background()
+
+	for i := 0; i < int(testLoginPolicy.FreeAttempts); i++ {
+		if err := u.DisableTOTP(ctx, 1, model.TwoFactorCodeInput{Code: wrongCode(t, secret)}); !errors.Is(err, model.ErrInvalidTwoFactorCode) {
+			t.Fatalf("guess %d: got %v", i+1, err)
+		}
+	}
+
+	code := totpAt(t, secret, helper.TOTPStep(time.Now()))
+	if err := u.DisableTOTP(ctx, 1, model.TwoFactorCodeInput{Code: code}); err != nil {
+		t.Fatalf("right code: %v", err)
+	}
+
+	repo := u.loginThrottle.repo.(*fakeLoginAttemptRepo)
+	if attempt, ok := repo.get(accountAttemptKey("user@example.com")); ok {
+		t.Errorf("account counter left at %d failures after a right code", attempt.Failures)
+	}
+}
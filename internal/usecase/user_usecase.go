@@ -3,77 +3,217 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/helper"
 	"github.com/tubagusmf/ecommerce-user-product-service/internal/model"
 	"github.com/tubagusmf/ecommerce-user-product-service/pb/user"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var v = validator.New()
+// mailTimeout bounds emails sent in the background, after the request that
+// triggered them has finished.
+const mailTimeout = 30 * time.Second
 
 type UserUsecase struct {
-	userRepo   model.IUserRepository
-	userClient user.UserServiceClient
+	userRepo                 model.IUserRepository
+	loginThrottle            *loginThrottle
+	twoFactorRepo            model.ITwoFactorRepository
+	twoFactor                model.TwoFactorPolicy
+	mailer                   model.Mailer
+	pendingMail              sync.WaitGroup
+	sessionCache             *sessionCache
+	refreshTokenTTL          time.Duration
+	passwordResetTTL         time.Duration
+	passwordResetURL         string
+	verificationTTL          time.Duration
+	verificationURL          string
+	verificationResendPeriod time.Duration
+	userClient               user.UserServiceClient
 }
 
 func NewUserUsecase(
 	userRepo model.IUserRepository,
+	loginAttemptRepo model.ILoginAttemptRepository,
+	loginPolicy model.LoginThrottlePolicy,
+	twoFactorRepo model.ITwoFactorRepository,
+	twoFactor model.TwoFactorPolicy,
+	mailer model.Mailer,
+	sessionCacheTTL time.Duration,
+	refreshTokenTTL time.Duration,
+	passwordResetTTL time.Duration,
+	passwordResetURL string,
+	verificationTTL time.Duration,
+	verificationURL string,
+	verificationResendPeriod time.Duration,
 	userClient user.UserServiceClient,
 ) model.IUserUsecase {
 	return &UserUsecase{
-		userRepo:   userRepo,
-		userClient: userClient,
+		userRepo:                 userRepo,
+		loginThrottle:            newLoginThrottle(loginAttemptRepo, loginPolicy),
+		twoFactorRepo:            twoFactorRepo,
+		twoFactor:                twoFactor,
+		mailer:                   mailer,
+		sessionCache:             newSessionCache(sessionCacheTTL),
+		refreshTokenTTL:          refreshTokenTTL,
+		passwordResetTTL:         passwordResetTTL,
+		passwordResetURL:         passwordResetURL,
+		verificationTTL:          verificationTTL,
+		verificationURL:          verificationURL,
+		verificationResendPeriod: verificationResendPeriod,
+		userClient:               userClient,
 	}
 }
 
-func (u *UserUsecase) Login(ctx context.Context, in model.LoginInput) (token string, err error) {
+// Login checks the password. The result carries the session tokens, or a
+// challenge to finish with CompleteLogin when the account uses two-factor
+// authentication.
+func (u *UserUsecase) Login(ctx context.Context, in model.LoginInput) (*model.LoginResult, error) {
 	log := logrus.WithFields(logrus.Fields{
 		"email": in.Email,
 	})
 
-	if err := v.Struct(in); err != nil {
+	if err := helper.Validator.Struct(in); err != nil {
 		log.Error("Validation error: ", err)
-		return "", err
+		return nil, err
+	}
+
+	attempts, err := u.loginThrottle.attempt(ctx, loginAttemptKeys(in.Email, in.IPAddress))
+	if err != nil {
+		if !errors.Is(err, model.ErrLoginThrottled) {
+			log.Error("Failed to check login attempts: ", err)
+		}
+		return nil, err
 	}
 
 	user := u.userRepo.FindByEmail(ctx, in.Email)
 	if user == nil {
-		return "", errors.New("wrong email or password")
+		checkDummyPassword(in.Password)
+		u.loginThrottle.recordFailure(ctx, attempts)
+		return nil, model.ErrInvalidCredentials
 	}
 
 	if !helper.CheckPasswordHash(in.Password, user.Password) {
-		return "", errors.New("mismatch password")
+		u.loginThrottle.recordFailure(ctx, attempts)
+		return nil, model.ErrInvalidCredentials
 	}
+	u.loginThrottle.release(ctx, attempts)
 
-	token, err = helper.GenerateToken(user.ID)
+	result, err := u.finishLogin(ctx, user, in.UserAgent, in.IPAddress)
 	if err != nil {
-		log.Error(err)
-		return "", err
+		log.Error("Failed to finish login: ", err)
+		return nil, err
+	}
+
+	// A challenge keeps the account counter, so it also slows down guessing
+	// of the second factor; CompleteLogin clears it. The IP counter is left
+	// alone: one valid account must not clear the failures an IP has piled
+	// up against others.
+	if result.Challenge == nil {
+		if err := u.loginThrottle.reset(ctx, accountAttemptKey(user.Email)); err != nil {
+			log.Error("Failed to reset login attempts: ", err)
+		}
 	}
 
-	err = u.userRepo.DeleteSession(ctx, token)
+	return result, nil
+}
+
+// startSession issues an access token and the first refresh token of a new
+// session for user. The session lasts as long as its refresh tokens keep
+// being rotated.
+func (u *UserUsecase) startSession(ctx context.Context, user *model.User, userAgent string, ipAddress string) (*model.TokenPair, error) {
+	accessToken, err := helper.GenerateToken(user.ID, user.Role)
 	if err != nil {
-		log.Warn("Failed to delete old session:", err)
+		return nil, err
 	}
 
-	session := model.UserSession{
+	refreshToken, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(u.refreshTokenTTL)
+	session, err := u.userRepo.CreateSession(ctx, model.UserSession{
 		UserID:    user.ID,
-		Token:     token,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		Token:     accessToken,
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+		ExpiresAt: expiresAt,
 		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
 	}
-	_, err = u.userRepo.CreateSession(ctx, session)
+
+	err = u.userRepo.CreateRefreshToken(ctx, model.RefreshToken{
+		UserID:    user.ID,
+		SessionID: session.ID,
+		TokenHash: helper.HashToken(refreshToken),
+		ExpiresAt: expiresAt,
+	})
 	if err != nil {
-		log.Error("Failed to create session:", err)
-		return "", err
+		return nil, err
 	}
 
-	return token, nil
+	return &model.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token. Each
+// refresh token works once; reusing one ends its session.
+func (u *UserUsecase) Refresh(ctx context.Context, refreshToken string) (*model.TokenPair, error) {
+	tokenHash := helper.HashToken(refreshToken)
+
+	current, err := u.userRepo.FindRefreshToken(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"user_id":    current.UserID,
+		"session_id": current.SessionID,
+	})
+
+	user, err := u.userRepo.FindById(ctx, current.UserID)
+	if err != nil {
+		log.Error("Failed to fetch user: ", err)
+		return nil, model.ErrRefreshTokenInvalid
+	}
+	if user.DeletedAt != nil {
+		return nil, model.ErrRefreshTokenInvalid
+	}
+
+	accessToken, err := helper.GenerateToken(user.ID, user.Role)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	nextToken, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	previousToken, err := u.userRepo.RotateRefreshToken(ctx, tokenHash, model.RefreshToken{
+		TokenHash: helper.HashToken(nextToken),
+		ExpiresAt: time.Now().Add(u.refreshTokenTTL),
+	}, accessToken)
+	if previousToken != "" {
+		u.sessionCache.delete(previousToken)
+	}
+	if err != nil {
+		if errors.Is(err, model.ErrRefreshTokenReused) {
+			log.Warn("Refresh token reuse detected, session revoked")
+		}
+		return nil, err
+	}
+
+	return &model.TokenPair{AccessToken: accessToken, RefreshToken: nextToken}, nil
 }
 func (u *UserUsecase) FindAll(ctx context.Context, user model.User) ([]*model.User, error) {
 	log := logrus.WithFields(logrus.Fields{
@@ -91,9 +231,11 @@ func (u *UserUsecase) FindAll(ctx context.Context, user model.User) ([]*model.Us
 
 func (u *UserUsecase) Logout(ctx context.Context, token string) error {
 	log := logrus.WithFields(logrus.Fields{
-		"token": token,
+		"token_hash": helper.HashToken(token),
 	})
 
+	u.sessionCache.delete(token)
+
 	err := u.userRepo.DeleteSession(ctx, token)
 	if err != nil {
 		log.Error("Failed to delete session: ", err)
@@ -105,19 +247,99 @@ func (u *UserUsecase) Logout(ctx context.Context, token string) error {
 }
 
 func (u *UserUsecase) ValidateSession(ctx context.Context, token string) (*model.UserSession, error) {
+	if session, ok := u.sessionCache.get(token); ok {
+		return session, nil
+	}
+
 	session, err := u.userRepo.FindSessionByToken(ctx, token)
 	if err != nil {
-		logrus.Error("Failed to fetch session: ", err)
+		if !errors.Is(err, model.ErrSessionNotFound) {
+			logrus.Error("Failed to fetch session: ", err)
+		}
 		return nil, err
 	}
 
 	if session == nil || session.ExpiresAt.Before(time.Now()) {
-		return nil, errors.New("session expired or not found")
+		return nil, model.ErrSessionNotFound
+	}
+
+	// Sessions are only read from the database after a cache miss, so
+	// last_seen_at is as fresh as the cache TTL.
+	now := time.Now()
+	if err := u.userRepo.TouchSession(ctx, session.ID, now); err != nil {
+		logrus.Warn("Failed to update session last seen: ", err)
 	}
+	session.LastSeenAt = &now
 
+	u.sessionCache.put(token, *session)
 	return session, nil
 }
 
+func (u *UserUsecase) ListSessions(ctx context.Context, userID int64, currentToken string) ([]*model.UserSession, error) {
+	sessions, err := u.userRepo.FindSessionsByUserID(ctx, userID)
+	if err != nil {
+		logrus.WithField("user_id", userID).Error("Failed to fetch sessions: ", err)
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Current = currentToken != "" && session.Token == currentToken
+	}
+
+	return sessions, nil
+}
+
+func (u *UserUsecase) RevokeSession(ctx context.Context, userID int64, sessionID int64) error {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"session_id": sessionID,
+	})
+
+	session, err := u.userRepo.DeleteSessionByID(ctx, userID, sessionID)
+	if err != nil {
+		log.Error("Failed to revoke session: ", err)
+		return err
+	}
+	u.sessionCache.delete(session.Token)
+
+	log.Info("Session revoked")
+	return nil
+}
+
+// RevokeOtherSessions ends every session of the user except the one that
+// currentToken belongs to.
+func (u *UserUsecase) RevokeOtherSessions(ctx context.Context, userID int64, currentToken string) error {
+	current, err := u.ValidateSession(ctx, currentToken)
+	if err != nil {
+		return err
+	}
+
+	return u.revokeSessions(ctx, userID, current.ID)
+}
+
+func (u *UserUsecase) RevokeAllSessions(ctx context.Context, userID int64) error {
+	return u.revokeSessions(ctx, userID, 0)
+}
+
+func (u *UserUsecase) revokeSessions(ctx context.Context, userID int64, exceptID int64) error {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+	})
+
+	sessions, err := u.userRepo.DeleteSessionsByUserID(ctx, userID, exceptID)
+	if err != nil {
+		log.Error("Failed to revoke sessions: ", err)
+		return err
+	}
+
+	for _, session := range sessions {
+		u.sessionCache.delete(session.Token)
+	}
+
+	log.Infof("Revoked %d sessions", len(sessions))
+	return nil
+}
+
 func (u *UserUsecase) FindById(ctx context.Context, id int64) (*model.User, error) {
 	log := logrus.WithFields(logrus.Fields{
 		"id": id,
@@ -131,13 +353,13 @@ func (u *UserUsecase) FindById(ctx context.Context, id int64) (*model.User, erro
 
 	if user == nil {
 		log.Error("User not found")
-		return nil, errors.New("user not found")
+		return nil, model.ErrUserNotFound
 	}
 
 	return user, nil
 }
 
-func (u *UserUsecase) Create(ctx context.Context, in model.CreateUserInput) (token string, err error) {
+func (u *UserUsecase) Create(ctx context.Context, in model.CreateUserInput) (*model.TokenPair, error) {
 	logger := logrus.WithFields(logrus.Fields{
 		"in": in,
 	})
@@ -145,28 +367,34 @@ func (u *UserUsecase) Create(ctx context.Context, in model.CreateUserInput) (tok
 	passwordHashed, err := helper.HashRequestPassword(in.Password)
 	if err != nil {
 		logger.Error(err)
-		return
+		return nil, err
 	}
 
 	newUser, err := u.userRepo.Create(ctx, model.User{
 		Name:     in.Name,
 		Email:    in.Email,
 		Password: passwordHashed,
-		Role:     in.Role,
+		Role:     model.RoleCustomer,
 	})
 
 	if err != nil {
 		logger.Error(err)
-		return
+		return nil, err
+	}
+
+	// Registration still succeeds when the email cannot be sent; the user
+	// can ask for it again.
+	if err := u.sendVerificationEmail(ctx, newUser); err != nil {
+		logger.Error("Failed to send verification email: ", err)
 	}
 
-	accessToken, err := helper.GenerateToken(newUser.ID)
+	tokens, err := u.startSession(ctx, newUser, in.UserAgent, in.IPAddress)
 	if err != nil {
 		logger.Error(err)
-		return
+		return nil, err
 	}
 
-	return accessToken, nil
+	return tokens, nil
 }
 
 func (u *UserUsecase) Update(ctx context.Context, id int64, in model.UpdateUserInput) error {
@@ -174,10 +402,9 @@ func (u *UserUsecase) Update(ctx context.Context, id int64, in model.UpdateUserI
 		"id":    id,
 		"name":  in.Name,
 		"email": in.Email,
-		"role":  in.Role,
 	})
 
-	err := v.StructCtx(ctx, in)
+	err := helper.Validator.StructCtx(ctx, in)
 	if err != nil {
 		log.Error("Validation error:", err)
 		return err
@@ -190,7 +417,7 @@ func (u *UserUsecase) Update(ctx context.Context, id int64, in model.UpdateUserI
 	}
 	if existingUser == nil || (existingUser.DeletedAt != nil && !existingUser.DeletedAt.IsZero()) {
 		log.Error("User is deleted or does not exist")
-		return errors.New("user is deleted or does not exist")
+		return model.ErrUserNotFound
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
@@ -204,7 +431,6 @@ func (u *UserUsecase) Update(ctx context.Context, id int64, in model.UpdateUserI
 		Name:      in.Name,
 		Password:  string(hashedPassword),
 		Email:     in.Email,
-		Role:      in.Role,
 		UpdatedAt: time.Now(),
 	}
 
@@ -214,6 +440,12 @@ func (u *UserUsecase) Update(ctx context.Context, id int64, in model.UpdateUserI
 		return err
 	}
 
+	if in.Email != existingUser.Email {
+		if err := u.sendVerificationEmail(ctx, &user); err != nil {
+			log.Error("Failed to send verification email: ", err)
+		}
+	}
+
 	return nil
 }
 
@@ -242,6 +474,238 @@ func (u *UserUsecase) Delete(ctx context.Context, id int64) error {
 		return err
 	}
 
+	// Soft deletion keeps the sessions, so end them here.
+	if err := u.revokeSessions(ctx, id, 0); err != nil {
+		return err
+	}
+
 	log.Info("Successfully deleted user with ID: ", id)
 	return nil
 }
+
+// ForgotPassword emails a password reset link to in.Email. Unknown
+// addresses get the same nil result, so the caller cannot tell which
+// emails are registered.
+func (u *UserUsecase) ForgotPassword(ctx context.Context, in model.ForgotPasswordInput) error {
+	if err := helper.Validator.StructCtx(ctx, in); err != nil {
+		return err
+	}
+
+	user := u.userRepo.FindByEmail(ctx, in.Email)
+	if user == nil {
+		return nil
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": user.ID,
+	})
+
+	token, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	link, err := tokenLink(u.passwordResetURL, token)
+	if err != nil {
+		log.Error("Invalid password reset URL: ", err)
+		return err
+	}
+
+	err = u.userRepo.CreatePasswordResetToken(ctx, model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: helper.HashToken(token),
+		ExpiresAt: time.Now().Add(u.passwordResetTTL),
+	})
+	if err != nil {
+		log.Error("Failed to save password reset token: ", err)
+		return err
+	}
+
+	email := model.Email{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Use the link below to choose a new password. It expires in %d minutes and works once.\n\n"+
+			"%s\n\n"+
+			"If you did not ask for a password reset, you can ignore this email.\n",
+			user.Name, int(u.passwordResetTTL.Minutes()), link),
+	}
+
+	// Sent in the background so a slow mail server does not make known
+	// addresses answer slower than unknown ones.
+	u.sendMailAsync(log, email)
+
+	return nil
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword and
+// signs the user out everywhere.
+func (u *UserUsecase) ResetPassword(ctx context.Context, in model.ResetPasswordInput) error {
+	if err := helper.Validator.StructCtx(ctx, in); err != nil {
+		return err
+	}
+
+	passwordHashed, err := helper.HashRequestPassword(in.Password)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	sessions, err := u.userRepo.ResetPassword(ctx, helper.HashToken(in.Token), passwordHashed)
+	if err != nil {
+		if !errors.Is(err, model.ErrPasswordResetTokenInvalid) {
+			logrus.Error("Failed to reset password: ", err)
+		}
+		return err
+	}
+
+	for _, session := range sessions {
+		u.sessionCache.delete(session.Token)
+	}
+
+	logrus.Infof("Password reset, %d sessions revoked", len(sessions))
+	return nil
+}
+
+func (u *UserUsecase) VerifyEmail(ctx context.Context, token string) error {
+	if token == "" {
+		return model.ErrEmailVerificationTokenInvalid
+	}
+
+	err := u.userRepo.VerifyEmail(ctx, helper.HashToken(token))
+	if err != nil && !errors.Is(err, model.ErrEmailVerificationTokenInvalid) {
+		logrus.Error("Failed to verify email: ", err)
+	}
+	return err
+}
+
+// ResendVerificationEmail sends a new verification link, at most once per
+// resend period.
+func (u *UserUsecase) ResendVerificationEmail(ctx context.Context, userID int64) error {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+	})
+
+	user, err := u.userRepo.FindById(ctx, userID)
+	if err != nil {
+		log.Error("Failed to fetch user: ", err)
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return model.ErrEmailAlreadyVerified
+	}
+
+	latest, err := u.userRepo.FindLatestEmailVerificationToken(ctx, userID)
+	if err != nil {
+		log.Error("Failed to fetch verification token: ", err)
+		return err
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < u.verificationResendPeriod {
+		return model.ErrVerificationResendTooSoon
+	}
+
+	if err := u.sendVerificationEmail(ctx, user); err != nil {
+		log.Error("Failed to send verification email: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (u *UserUsecase) sendVerificationEmail(ctx context.Context, user *model.User) error {
+	token, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	link, err := tokenLink(u.verificationURL, token)
+	if err != nil {
+		return err
+	}
+
+	err = u.userRepo.CreateEmailVerificationToken(ctx, model.EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: helper.HashToken(token),
+		ExpiresAt: time.Now().Add(u.verificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	u.sendMailAsync(logrus.WithField("user_id", user.ID), model.Email{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm your email address by opening the link below. It expires in %d hours.\n\n"+
+			"%s\n",
+			user.Name, int(u.verificationTTL.Hours()), link),
+	})
+	return nil
+}
+
+// sendMailAsync sends email without holding up the request; failures are
+// only logged.
+func (u *UserUsecase) sendMailAsync(log *logrus.Entry, email model.Email) {
+	u.pendingMail.Add(1)
+	go func() {
+		defer u.pendingMail.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+
+		if err := u.mailer.Send(ctx, email); err != nil {
+			log.Errorf("Failed to send %q email: %v", email.Subject, err)
+		}
+	}()
+}
+
+func (u *UserUsecase) WaitForMail(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		u.pendingMail.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tokenLink appends token to base as the "token" query parameter.
+func tokenLink(base string, token string) (string, error) {
+	link, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// UnlockAccount clears the failed logins recorded for the user's email,
+// lifting any lockout.
+func (u *UserUsecase) UnlockAccount(ctx context.Context, userID int64) error {
+	log := logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+	})
+
+	user, err := u.userRepo.FindById(ctx, userID)
+	if err != nil {
+		log.Error("Failed to fetch user: ", err)
+		return err
+	}
+
+	if err := u.loginThrottle.reset(ctx, accountAttemptKey(user.Email)); err != nil {
+		log.Error("Failed to unlock account: ", err)
+		return err
+	}
+
+	log.Info("Account unlocked")
+	return nil
+}
@@ -1,6 +1,12 @@
 package main
 
-import "github.com/tubagusmf/ecommerce-user-product-service/internal/console"
+import (
+	// Order IDs are dated in a configured time zone; embed the zone
+	// database so it loads on hosts without one.
+	_ "time/tzdata"
+
+	"github.com/tubagusmf/ecommerce-user-product-service/internal/console"
+)
 
 func main() {
 	console.Execute()